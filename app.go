@@ -1,8 +1,15 @@
 package main
 
 import (
+	"Achievement-Thing/internal/parser"
+	"Achievement-Thing/internal/settingservice"
+	"Achievement-Thing/internal/steam"
+	"Achievement-Thing/internal/watcherservice"
 	"context"
 	"fmt"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // App struct
@@ -19,9 +26,61 @@ func NewApp() *App {
 // so we can call the runtime methods
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	watcherservice.OnEvent(func(event watcherservice.Event) {
+		runtime.EventsEmit(a.ctx, "watcher:event", event)
+	})
 }
 
 // Greet returns a greeting for the given name
 func (a *App) Greet(name string) string {
 	return fmt.Sprintf("Hello %s, It's show time!", name)
 }
+
+// SetAchievementState locks or unlocks an achievement in an emulator's
+// achievement file, so the notification pipeline can be tested by hand
+func (a *App) SetAchievementState(path string, name string, achieved bool) error {
+	return parser.SetAchieved(path, name, achieved, time.Now())
+}
+
+// GetHistory returns the most recent unlock, progress and stat events
+func (a *App) GetHistory() []watcherservice.Event {
+	return watcherservice.History()
+}
+
+// GetStats returns the last known stat values for a game and profile
+func (a *App) GetStats(profile string, appId string) map[string]parser.Stat {
+	return watcherservice.Stats(profile, appId)
+}
+
+// GetSource returns the emulator a game's achievements for a profile were
+// last read from
+func (a *App) GetSource(profile string, appId string) string {
+	return watcherservice.Source(profile, appId)
+}
+
+// GetUnresolvedFiles returns the achievement files whose game couldn't be
+// worked out, so an app ID override can be added for them in the settings
+func (a *App) GetUnresolvedFiles() []watcherservice.UnresolvedFile {
+	return watcherservice.UnresolvedFiles()
+}
+
+// GetRarity returns the share of players, in percent, that has unlocked each
+// of a game's achievements, keyed by API name
+func (a *App) GetRarity(appId string) (map[string]float64, error) {
+	return steam.GetRarity(a.ctx, appId)
+}
+
+// GetSettings returns the saved settings
+func (a *App) GetSettings() (settingservice.Settings, error) {
+	return settingservice.LoadSettings()
+}
+
+// SaveSettings saves the settings and applies the ones that can change
+// while running, filling in achievement metadata that a new API key or
+// schema source makes available
+func (a *App) SaveSettings(settings settingservice.Settings) error {
+	if err := settingservice.SaveSettings(settings); err != nil {
+		return err
+	}
+	return watcherservice.ReloadSettings()
+}
@@ -1,26 +1,23 @@
 package helper
 
 import (
+	"Achievement-Thing/internal/parser"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 )
 
-func FindFilesRecursive(folder string, matchFiles []string) ([]string, error) {
+func FindFilesFunc(folder string, match func(path string) bool) ([]string, error) {
 	var results []string
 	err := filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() {
-			lowerPath := strings.ToLower(path)
-			for _, f := range matchFiles {
-				if strings.HasSuffix(lowerPath, strings.ToLower(f)) {
-					results = append(results, path)
-					break
-				}
-			}
+		if !info.IsDir() && match(path) {
+			results = append(results, path)
 		}
 		return nil
 	})
@@ -30,13 +27,241 @@ func FindFilesRecursive(folder string, matchFiles []string) ([]string, error) {
 	return results, nil
 }
 
+// appIdTemplates are the folder layouts emulators save under, with {appid}
+// marking the folder named by the app ID and * matching any one folder.
+// They are matched case-insensitively against consecutive folders of a path.
+var appIdTemplates = []string{
+	"CODEX/{appid}",
+	"RUNE/{appid}",
+	"OnlineFix/{appid}",
+	"OnlineFix/*/{appid}",
+	"EMPRESS/{appid}",
+	"EMPRESS/remote/{appid}",
+	"SmartSteamEmu/{appid}",
+	"CreamAPI/{appid}",
+	"skidrow/{appid}",
+	"ALI213/{appid}",
+	"Goldberg SteamEmu Saves/{appid}",
+	"GSE Saves/{appid}",
+	"ProgramData/Steam/*/{appid}",
+}
+
+// maxAppIdFileDepth is how many folders above an achievement file are
+// searched for a steam_appid.txt.
+const maxAppIdFileDepth = 3
+
+var appIdOverrides map[string]string
+var appIdOverridesMutex sync.RWMutex
+
+// SetAppIdOverrides sets the user's path to app ID overrides. Each key is a
+// file or folder; files in it get its app ID.
+func SetAppIdOverrides(overrides map[string]string) {
+	appIdOverridesMutex.Lock()
+	defer appIdOverridesMutex.Unlock()
+	appIdOverrides = overrides
+}
+
+// ExtractAppId works out which game an achievement file belongs to. User
+// overrides win, then IDs the file's name or emulator layout spells out,
+// and last a steam_appid.txt near the file. It returns "" when none of them
+// apply; a numeric folder alone isn't taken for an app ID, as it may just as
+// well be a year or a version.
 func ExtractAppId(filePath string) string {
+	if appId := overriddenAppId(filePath); appId != "" {
+		return appId
+	}
+	if _, appId, ok := parser.SplitUserGameStatsName(filePath); ok {
+		return appId
+	}
+	if _, appId, ok := splitEmulatorPath(filePath); ok {
+		return appId
+	}
 	sep := string(os.PathSeparator)
-	parts := strings.Split(filePath, sep)
+	parts := strings.Split(filepath.Dir(filePath), sep)
 	for _, p := range parts {
-		if _, err := strconv.Atoi(p); err == nil {
+		if npCommId.MatchString(p) {
 			return p
 		}
 	}
+	for _, template := range appIdTemplates {
+		if appId := matchAppIdTemplate(parts, strings.Split(template, "/")); appId != "" {
+			return appId
+		}
+	}
+	return findSteamAppIdFile(filepath.Dir(filePath))
+}
+
+// overriddenAppId returns the app ID of the most specific override that
+// covers filePath.
+func overriddenAppId(filePath string) string {
+	appIdOverridesMutex.RLock()
+	defer appIdOverridesMutex.RUnlock()
+	best, bestLen := "", 0
+	for prefix, appId := range appIdOverrides {
+		prefix = filepath.Clean(prefix)
+		if len(prefix) <= bestLen || !hasPathPrefix(filePath, prefix) {
+			continue
+		}
+		best, bestLen = appId, len(prefix)
+	}
+	return best
+}
+
+// hasPathPrefix reports whether path is prefix or lies inside it, ignoring
+// case as Windows does.
+func hasPathPrefix(path string, prefix string) bool {
+	path = filepath.Clean(path)
+	if len(path) < len(prefix) || !strings.EqualFold(path[:len(prefix)], prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || os.IsPathSeparator(path[len(prefix)]) || os.IsPathSeparator(prefix[len(prefix)-1])
+}
+
+// matchAppIdTemplate finds template in parts and returns the folder in the
+// {appid} position, which must be a Steam app ID. The last match wins, as
+// it is the closest to the file.
+func matchAppIdTemplate(parts []string, template []string) string {
+	appId := ""
+	for start := 0; start+len(template) <= len(parts); start++ {
+		candidate := ""
+		matched := true
+		for i, t := range template {
+			part := parts[start+i]
+			switch {
+			case t == "{appid}" && IsSteamAppId(part):
+				candidate = part
+			case t == "*" || strings.EqualFold(t, part):
+			default:
+				matched = false
+			}
+			if !matched {
+				break
+			}
+		}
+		if matched && candidate != "" {
+			appId = candidate
+		}
+	}
+	return appId
+}
+
+// findSteamAppIdFile looks for the steam_appid.txt that games and Goldberg
+// setups keep next to the executable, in dir and a few folders above it.
+func findSteamAppIdFile(dir string) string {
+	for depth := 0; depth <= maxAppIdFileDepth; depth++ {
+		for _, name := range []string{"steam_appid.txt", filepath.Join("steam_settings", "steam_appid.txt")} {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				continue
+			}
+			if appId := strings.TrimSpace(string(data)); IsSteamAppId(appId) {
+				return appId
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
 	return ""
 }
+
+// ExtractProfile works out which user an achievement file belongs to, for
+// emulators that keep a folder per account. It returns "" for files that
+// aren't tied to a particular profile.
+func ExtractProfile(filePath string) string {
+	if account, _, ok := parser.SplitUserGameStatsName(filePath); ok {
+		return account
+	}
+	if profile, _, ok := splitEmulatorPath(filePath); ok {
+		return profile
+	}
+	sep := string(os.PathSeparator)
+	parts := strings.Split(filePath, sep)
+	for _, p := range parts {
+		if isSteamID64(p) {
+			return p
+		}
+	}
+
+	// RPCS3 keeps trophies under dev_hdd0/home/<user>/trophy.
+	for i := 1; i+1 < len(parts); i++ {
+		if strings.EqualFold(parts[i-1], "home") && strings.EqualFold(parts[i+1], "trophy") {
+			return parts[i]
+		}
+	}
+
+	return goldbergAccount(filepath.Dir(filePath))
+}
+
+// goldbergAccounts caches the account found for each folder, since every
+// file event would otherwise read its way up to the root.
+var goldbergAccounts = make(map[string]string)
+var goldbergAccountsMutex sync.Mutex
+
+// goldbergAccount returns the account of the Goldberg save root dir lies in,
+// which Goldberg keeps in the root's settings folder, or "".
+func goldbergAccount(dir string) string {
+	goldbergAccountsMutex.Lock()
+	defer goldbergAccountsMutex.Unlock()
+	if account, ok := goldbergAccounts[dir]; ok {
+		return account
+	}
+
+	account := ""
+search:
+	for d := dir; d != filepath.Dir(d); d = filepath.Dir(d) {
+		for _, name := range []string{"user_steam_id.txt", "account_name.txt"} {
+			data, err := os.ReadFile(filepath.Join(d, "settings", name))
+			if err == nil && strings.TrimSpace(string(data)) != "" {
+				account = strings.TrimSpace(string(data))
+				break search
+			}
+		}
+	}
+	goldbergAccounts[dir] = account
+	return account
+}
+
+// emulatorIdPrefixes are the save roots of emulators for non-Steam stores,
+// with the prefix their game IDs get so they can't be mistaken for Steam app
+// IDs: Ubisoft's IDs are numbers too.
+var emulatorIdPrefixes = map[string]string{
+	strings.ToLower(parser.NemirtingasRoot): "epic-",
+	strings.ToLower(parser.LumaPlayRoot):    "uplay-",
+}
+
+// splitEmulatorPath reads the account and game ID out of a path laid out as
+// <root>/<account>/<game>/..., which is how the Nemirtingas Epic emulator
+// and LumaPlay store their saves.
+func splitEmulatorPath(filePath string) (profile string, appId string, ok bool) {
+	parts := strings.Split(filePath, string(os.PathSeparator))
+	for i := 0; i+3 < len(parts); i++ {
+		if prefix, known := emulatorIdPrefixes[strings.ToLower(parts[i])]; known {
+			return parts[i+1], prefix + parts[i+2], true
+		}
+	}
+	return "", "", false
+}
+
+// npCommId matches PlayStation trophy set IDs such as NPWR00001_00, which
+// RPCS3 uses as trophy folder names in place of an app ID.
+var npCommId = regexp.MustCompile(`^NPWR\d{5}_\d{2}$`)
+
+// IsSteamAppId reports whether a game ID is a Steam app ID, as opposed to
+// an identifier from another platform such as a PlayStation trophy set.
+func IsSteamAppId(id string) bool {
+	_, err := strconv.ParseUint(id, 10, 32)
+	return err == nil
+}
+
+// isSteamID64 reports whether s is a 64-bit Steam account ID, which some
+// emulators use as a per-user folder name.
+func isSteamID64(s string) bool {
+	if len(s) != 17 || !strings.HasPrefix(s, "7656119") {
+		return false
+	}
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil
+}
@@ -0,0 +1,102 @@
+package helper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtractAppId(t *testing.T) {
+	root := t.TempDir()
+	gameDir := filepath.Join(root, "Games", "Test Game")
+	if err := os.MkdirAll(filepath.Join(gameDir, "steam_settings"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(gameDir, "steam_settings", "steam_appid.txt"), []byte("620\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"CODEX", filepath.Join("Public", "Documents", "Steam", "CODEX", "480", "achievements.ini"), "480"},
+		{"EMPRESS remote", filepath.Join("AppData", "EMPRESS", "remote", "480", "achievements.json"), "480"},
+		{"template is case-insensitive", filepath.Join("Saves", "codex", "480", "achievements.ini"), "480"},
+		{"OnlineFix with a Steam account", filepath.Join("Public", "Documents", "OnlineFix", "76561198000000001", "480", "Stats", "achievements.ini"), "480"},
+		{"numeric folder", filepath.Join("D:", "Saves", "480", "achievements.ini"), ""},
+		{"year folder", filepath.Join("D:", "2024", "Games", "Foo", "achievements.ini"), ""},
+		{"only a Steam account", filepath.Join("Saves", "76561198000000001", "achievements.ini"), ""},
+		{"UserGameStats", filepath.Join("Steam", "appcache", "stats", "UserGameStats_12345_480.bin"), "480"},
+		{"Nemirtingas", filepath.Join("AppData", "NemirtingasEpicEmu", "account", "fortnite", "achievements.json"), "epic-fortnite"},
+		{"LumaPlay", filepath.Join("AppData", "LumaPlay", "account", "46", "achievements.ini"), "uplay-46"},
+		{"RPCS3", filepath.Join("dev_hdd0", "home", "00000001", "trophy", "NPWR00001_00", "TROPUSR.DAT"), "NPWR00001_00"},
+		{"steam_appid.txt", filepath.Join(gameDir, "saves", "achievements.json"), "620"},
+		{"nothing to go on", filepath.Join("Saves", "achievements.ini"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractAppId(tt.path); got != tt.want {
+				t.Errorf("ExtractAppId(%s) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractAppIdOverrides(t *testing.T) {
+	saves := filepath.Join("Saves", "CODEX")
+	SetAppIdOverrides(map[string]string{
+		saves:                           "100",
+		filepath.Join(saves, "480"):     "200",
+		filepath.Join("Saves", "Other"): "300",
+	})
+	t.Cleanup(func() { SetAppIdOverrides(nil) })
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{filepath.Join(saves, "480", "achievements.ini"), "200"},
+		{filepath.Join(saves, "620", "achievements.ini"), "100"},
+		{filepath.Join("saves", "other", "achievements.ini"), "300"},
+		{filepath.Join("Saves", "Others", "1", "achievements.ini"), ""},
+	}
+	for _, tt := range tests {
+		if got := ExtractAppId(tt.path); got != tt.want {
+			t.Errorf("ExtractAppId(%s) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestExtractProfile(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "settings"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "settings", "account_name.txt"), []byte("player\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"UserGameStats", filepath.Join("stats", "UserGameStats_12345_480.bin"), "12345"},
+		{"Steam account folder", filepath.Join("OnlineFix", "76561198000000001", "480", "achievements.ini"), "76561198000000001"},
+		{"Nemirtingas", filepath.Join("NemirtingasEpicEmu", "account", "fortnite", "achievements.json"), "account"},
+		{"RPCS3", filepath.Join("dev_hdd0", "home", "00000001", "trophy", "NPWR00001_00", "TROPUSR.DAT"), "00000001"},
+		{"Goldberg settings", filepath.Join(root, "480", "achievements.json"), "player"},
+		{"no profile", filepath.Join(t.TempDir(), "480", "achievements.json"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractProfile(tt.path); got != tt.want {
+				t.Errorf("ExtractProfile(%s) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
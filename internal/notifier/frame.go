@@ -0,0 +1,88 @@
+package notifier
+
+import (
+	"Achievement-Thing/pkg/atomicfile"
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var framesDir = filepath.Join(os.TempDir(), "Achievement-Thing", "frames")
+
+// frameIcon draws a frame of the given color around an icon and returns the
+// path of the framed copy. Copies are kept, so each version of an icon is
+// framed once per color; a changed icon file gets a new copy.
+func frameIcon(icon string, frameColor string) (string, error) {
+	c, err := parseHexColor(frameColor)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(icon)
+	if err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("%s\x00%s\x00%d\x00%d", icon, frameColor, info.Size(), info.ModTime().UnixNano())
+	framedPath := filepath.Join(framesDir, fmt.Sprintf("%x.png", sha1.Sum([]byte(key))))
+	if _, err := os.Stat(framedPath); err == nil {
+		return framedPath, nil
+	}
+
+	f, err := os.Open(icon)
+	if err != nil {
+		return "", err
+	}
+	src, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return "", fmt.Errorf("error decoding icon: %v", err)
+	}
+
+	bounds := src.Bounds()
+	framed := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(framed, framed.Bounds(), src, bounds.Min, draw.Src)
+
+	width := max(2, min(bounds.Dx(), bounds.Dy())/16)
+	frame := image.NewUniform(c)
+	r := framed.Bounds()
+	for _, edge := range []image.Rectangle{
+		image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+width),
+		image.Rect(r.Min.X, r.Max.Y-width, r.Max.X, r.Max.Y),
+		image.Rect(r.Min.X, r.Min.Y, r.Min.X+width, r.Max.Y),
+		image.Rect(r.Max.X-width, r.Min.Y, r.Max.X, r.Max.Y),
+	} {
+		draw.Draw(framed, edge, frame, image.Point{}, draw.Over)
+	}
+
+	// Written atomically, so a notification never shows a half-written
+	// copy and a failed write leaves nothing behind to be taken as framed.
+	var out bytes.Buffer
+	if err := png.Encode(&out, framed); err != nil {
+		return "", err
+	}
+	if err := atomicfile.WriteFile(framedPath, out.Bytes()); err != nil {
+		return "", err
+	}
+	return framedPath, nil
+}
+
+func parseHexColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("invalid frame color %q", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid frame color %q", s)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
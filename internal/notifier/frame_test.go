@@ -0,0 +1,125 @@
+package notifier
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeIcon saves a square PNG of one color at path.
+func writeIcon(t *testing.T, path string, size int, c color.RGBA) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func useFramesDir(t *testing.T) string {
+	t.Helper()
+	saved := framesDir
+	framesDir = t.TempDir()
+	t.Cleanup(func() { framesDir = saved })
+	return framesDir
+}
+
+func TestFrameIcon(t *testing.T) {
+	dir := useFramesDir(t)
+	icon := filepath.Join(t.TempDir(), "icon.png")
+	blue := color.RGBA{B: 0xff, A: 0xff}
+	writeIcon(t, icon, 64, blue)
+
+	framedPath, err := frameIcon(icon, "#ffd700")
+	if err != nil {
+		t.Fatalf("frameIcon: %v", err)
+	}
+	f, err := os.Open(framedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	framed, err := png.Decode(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := color.RGBAModel.Convert(framed.At(0, 0)); got != (color.RGBA{R: 0xff, G: 0xd7, A: 0xff}) {
+		t.Errorf("corner = %v, want the frame color", got)
+	}
+	if got := color.RGBAModel.Convert(framed.At(32, 32)); got != blue {
+		t.Errorf("center = %v, want the icon", got)
+	}
+
+	// The framed copy is reused for the same icon and color only.
+	if again, err := frameIcon(icon, "#ffd700"); err != nil || again != framedPath {
+		t.Errorf("second frameIcon = %q, %v; want %q", again, err, framedPath)
+	}
+	if other, err := frameIcon(icon, "#c0c0c0"); err != nil || other == framedPath {
+		t.Errorf("frameIcon in another color = %q, %v", other, err)
+	}
+
+	// A changed icon at the same path is framed afresh.
+	writeIcon(t, icon, 32, color.RGBA{R: 0xff, A: 0xff})
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(icon, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := frameIcon(icon, "#ffd700"); err != nil || changed == framedPath {
+		t.Errorf("frameIcon after the icon changed = %q, %v", changed, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if filepath.Ext(e.Name()) != ".png" {
+			t.Errorf("left %s behind in the frames folder", e.Name())
+		}
+	}
+}
+
+func TestFrameIconErrors(t *testing.T) {
+	dir := useFramesDir(t)
+	icon := filepath.Join(t.TempDir(), "icon.png")
+	writeIcon(t, icon, 16, color.RGBA{A: 0xff})
+	notAnImage := filepath.Join(t.TempDir(), "icon.txt")
+	if err := os.WriteFile(notAnImage, []byte("not an image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		icon  string
+		color string
+	}{
+		{"bad color", icon, "gold"},
+		{"short color", icon, "#fff"},
+		{"missing icon", filepath.Join(t.TempDir(), "missing.png"), "#ffd700"},
+		{"not an image", notAnImage, "#ffd700"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if path, err := frameIcon(tt.icon, tt.color); err == nil {
+				t.Errorf("expected an error, got %s", path)
+			}
+		})
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("failed framings left %d files behind", len(entries))
+	}
+}
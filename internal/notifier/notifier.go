@@ -5,14 +5,53 @@ import (
 	"fmt"
 )
 
-func SendAchievement(title, message, icon string) error {
+// DefaultAchievementSound is played for unlocks whose style sets no sound.
+const DefaultAchievementSound = "ms-winsoundevent:Notification.AchievementThing"
+
+// Style sets how an unlock notification looks and sounds. The zero Style is
+// the plain notification.
+type Style struct {
+	Sound       string
+	Attribution string
+	// FrameColor, as "#rrggbb", draws a frame of that color around the icon.
+	FrameColor string
+}
+
+func SendAchievement(title, message, icon string, style Style) error {
 	fmt.Println("Sending achievement notification:", title)
+	if icon != "" && style.FrameColor != "" {
+		framed, err := frameIcon(icon, style.FrameColor)
+		if err != nil {
+			fmt.Println("Error framing achievement icon:", err)
+		} else {
+			icon = framed
+		}
+	}
+	sound := style.Sound
+	if sound == "" {
+		sound = DefaultAchievementSound
+	}
+
+	notification := toast.Toast{
+		AppID:       "Microsoft.XboxGamingOverlay_8wekyb3d8bbwe!App",
+		Title:       title,
+		Message:     message,
+		Icon:        icon,
+		Audio:       sound,
+		Attribution: style.Attribution,
+	}
+
+	return notification.Show()
+}
+
+func SendProgress(name string, current, max int, icon string) error {
+	fmt.Println("Sending progress notification:", name, current, "/", max)
 	notification := toast.Toast{
 		AppID:   "Microsoft.XboxGamingOverlay_8wekyb3d8bbwe!App",
-		Title:   title,
-		Message: message,
+		Title:   "Achievement progress",
+		Message: fmt.Sprintf("%s: %d/%d", name, current, max),
 		Icon:    icon,
-		Audio:   "ms-winsoundevent:Notification.AchievementThing",
+		Audio:   toast.Default,
 	}
 
 	return notification.Show()
@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	FormatINI          = "ini"
+	FormatJSON         = "json"
+	FormatGoldbergStat = "goldberg-stat"
+)
+
+const (
+	DialectGeneric        = "generic"
+	DialectCodex          = "codex"
+	DialectOnlineFix      = "onlinefix"
+	DialectAli213         = "ali213"
+	DialectGoldberg       = "goldberg"
+	DialectGoldbergLegacy = "goldberg-legacy"
+)
+
+func init() {
+	Register(Format{
+		Name:     FormatJSON,
+		Patterns: []string{"achievements.json"},
+		Sniff:    sniffJSON,
+		Decode:   parseJSON,
+		Encode:   encodeGoldberg,
+	})
+	Register(Format{
+		Name:     FormatINI,
+		Patterns: []string{"achievements.ini", "achiev.ini", "stats.ini"},
+		Sniff:    sniffINI,
+		Decode:   parseINI,
+		Encode:   encodeINI,
+	})
+}
+
+// DetectFormat works out which registered format should read data. The
+// content decides; the filename only breaks ties between formats the content
+// is compatible with. It returns "" when the format is not recognised.
+func DetectFormat(data []byte, filename string) string {
+	if f, ok := detect(data, filename); ok {
+		return f.Name
+	}
+	return ""
+}
+
+func detect(data []byte, filename string) (Format, bool) {
+	registered := Formats()
+
+	// Formats recognised by their full path are more specific than the
+	// base name patterns they may share with others, so they go first.
+	for _, f := range registered {
+		if f.Match != nil && f.Match(filename) && f.Sniff(data) {
+			return f, true
+		}
+	}
+	for _, f := range registered {
+		if f.matchName(filename) && f.Sniff(data) {
+			return f, true
+		}
+	}
+	for _, f := range registered {
+		if !f.NameRequired && f.matchExt(filename) && f.Sniff(data) {
+			return f, true
+		}
+	}
+	for _, f := range registered {
+		if !f.NameRequired && f.Sniff(data) {
+			return f, true
+		}
+	}
+	return Format{}, false
+}
+
+// detectData works out the format of a file's raw data and the bytes its
+// decoder should be given. Text is transcoded to UTF-8 first, but binary
+// formats that are recognised by name get the data as it is, since their
+// bytes can happen to look like a byte order mark or UTF-16.
+func detectData(raw []byte, filename string) (Format, []byte, Encoding, bool) {
+	for _, f := range Formats() {
+		if f.NameRequired && f.matchName(filename) && f.Sniff(raw) {
+			return f, raw, EncodingUTF8, true
+		}
+	}
+	data, encoding := decodeText(raw)
+	f, ok := detect(data, filename)
+	return f, data, encoding, ok
+}
+
+func sniffJSON(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return false
+	}
+	return json.Valid(trimmed)
+}
+
+// sniffINI reports whether data looks like an INI file: text made of section
+// headers, key=value pairs and comments, with at least one section.
+func sniffINI(data []byte) bool {
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return false
+	}
+
+	hasSection := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "", strings.HasPrefix(line, ";"), strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			hasSection = true
+		case strings.Contains(line, "="):
+		default:
+			return false
+		}
+	}
+	return hasSection && scanner.Err() == nil
+}
+
+// detectINIDialect guesses which emulator wrote an INI file from the set of
+// (lowercased) keys it contains.
+func detectINIDialect(keys map[string]bool) string {
+	switch {
+	case keys["unlocktime"]:
+		return DialectCodex
+	case keys["timestamp"]:
+		return DialectOnlineFix
+	case keys["haveachieved"]:
+		return DialectAli213
+	default:
+		return DialectGeneric
+	}
+}
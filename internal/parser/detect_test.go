@@ -0,0 +1,60 @@
+package parser
+
+import "testing"
+
+func TestDetectFormat(t *testing.T) {
+	codexINI := "[SteamAchievements]\nCount=1\n\n[ACH_WIN]\nAchieved=1\nUnlockTime=1700000000\n"
+	goldbergJSON := `{"ACH_WIN": {"earned": true, "earned_time": 1700000000}}`
+
+	tests := []struct {
+		name     string
+		data     string
+		filename string
+		want     string
+	}{
+		{"INI by name", codexINI, "achievements.ini", FormatINI},
+		{"JSON by name", goldbergJSON, "achievements.json", FormatJSON},
+		{"INI renamed to .json", codexINI, "achievements.json", FormatINI},
+		{"JSON renamed to .ini", goldbergJSON, "achievements.ini", FormatJSON},
+		{"INI without extension", codexINI, "achievements", FormatINI},
+		{"JSON with unknown extension", goldbergJSON, "achievements.bak", FormatJSON},
+		{"comments before the first section", "; written by CODEX\n[ACH_WIN]\nAchieved=1\n", "achiev.ini", FormatINI},
+		{"plain text", "just some notes\nnothing to see", "notes.txt", ""},
+		{"INI without sections", "Achieved=1\n", "achievements.ini", ""},
+		{"truncated JSON", `{"ACH_WIN": {"earned": tr`, "achievements.json", ""},
+		{"binary data", "\x00\x01\x02\x03\x04\x05", "achievements.ini", ""},
+		{"empty file", "", "achievements.json", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectFormat([]byte(tt.data), tt.filename); got != tt.want {
+				t.Errorf("DetectFormat(%q) = %q, want %q", tt.filename, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectINIDialect(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{"[ACH_WIN]\nAchieved=1\nUnlockTime=1700000000\n", DialectCodex},
+		{"[ACH_WIN]\nachieved=true\ntimestamp=1700000000\n", DialectOnlineFix},
+		{"[ACH_WIN]\nHaveAchieved=1\nHaveAchievedTime=1700000000\n", DialectAli213},
+		{"[ACH_WIN]\nAchieved=1\n", DialectGeneric},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			result, err := parseINI(&Input{Path: "achievements.ini", Data: []byte(tt.data)})
+			if err != nil {
+				t.Fatalf("parseINI: %v", err)
+			}
+			if result.Dialect != tt.want {
+				t.Errorf("dialect = %s, want %s", result.Dialect, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf16"
+)
+
+// Encoding is the text encoding an achievement file was written in, and
+// whether it started with a byte order mark.
+type Encoding string
+
+const (
+	EncodingUTF8       Encoding = "utf-8"
+	EncodingUTF8BOM    Encoding = "utf-8-bom"
+	EncodingUTF16LE    Encoding = "utf-16le"
+	EncodingUTF16LEBOM Encoding = "utf-16le-bom"
+	EncodingUTF16BE    Encoding = "utf-16be"
+	EncodingUTF16BEBOM Encoding = "utf-16be-bom"
+)
+
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// decodeText transcodes data to UTF-8 without a byte order mark. Files with a
+// BOM are decoded according to it; UTF-16 without a BOM is recognised by its
+// leading characters. Anything else, including binary files, is returned
+// unchanged.
+func decodeText(data []byte) ([]byte, Encoding) {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return data[len(bomUTF8):], EncodingUTF8BOM
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return decodeUTF16(data[len(bomUTF16LE):], binary.LittleEndian), EncodingUTF16LEBOM
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return decodeUTF16(data[len(bomUTF16BE):], binary.BigEndian), EncodingUTF16BEBOM
+	case looksLikeUTF16(data, binary.LittleEndian):
+		return decodeUTF16(data, binary.LittleEndian), EncodingUTF16LE
+	case looksLikeUTF16(data, binary.BigEndian):
+		return decodeUTF16(data, binary.BigEndian), EncodingUTF16BE
+	}
+	return data, EncodingUTF8
+}
+
+// looksLikeUTF16 reports whether data starts with two plain ASCII characters
+// encoded as UTF-16 in the given byte order, which is how the BOM-less
+// UTF-16 files we've seen begin ("[S", "{\r", ...).
+func looksLikeUTF16(data []byte, order binary.ByteOrder) bool {
+	if len(data) < 4 || len(data)%2 != 0 {
+		return false
+	}
+	for i := 0; i < 4; i += 2 {
+		r := order.Uint16(data[i:])
+		if r != '\t' && r != '\n' && r != '\r' && (r < 0x20 || r > 0x7e) {
+			return false
+		}
+	}
+	return true
+}
+
+func decodeUTF16(data []byte, order binary.ByteOrder) []byte {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[i*2:])
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(units))
+	for _, r := range utf16.Decode(units) {
+		buf.WriteRune(r)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		want     string
+		encoding Encoding
+	}{
+		{"UTF-8", []byte("[ACH_WIN]\n"), "[ACH_WIN]\n", EncodingUTF8},
+		{"UTF-8 with BOM", []byte("\xef\xbb\xbf[ACH_WIN]\n"), "[ACH_WIN]\n", EncodingUTF8BOM},
+		{"UTF-16LE with BOM", []byte("\xff\xfe[\x00\xe9\x00]\x00"), "[é]", EncodingUTF16LEBOM},
+		{"UTF-16BE with BOM", []byte("\xfe\xff\x00[\x00\xe9\x00]"), "[é]", EncodingUTF16BEBOM},
+		{"UTF-16LE without BOM", []byte("[\x00A\x00]\x00\n\x00"), "[A]\n", EncodingUTF16LE},
+		{"UTF-16BE without BOM", []byte("\x00[\x00A\x00]\x00\n"), "[A]\n", EncodingUTF16BE},
+		{"surrogate pair", []byte("\xff\xfe=\xd8\x00\xde"), "😀", EncodingUTF16LEBOM},
+		{"binary", []byte{0x00, 0x01, 0x02, 0x03, 0x04}, "\x00\x01\x02\x03\x04", EncodingUTF8},
+		{"odd length UTF-16", []byte("[\x00A\x00]"), "[\x00A\x00]", EncodingUTF8},
+		{"empty", nil, "", EncodingUTF8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, encoding := decodeText(tt.data)
+			if string(got) != tt.want || encoding != tt.encoding {
+				t.Errorf("decodeText = %q, %s; want %q, %s", got, encoding, tt.want, tt.encoding)
+			}
+		})
+	}
+}
+
+func TestParseUTF16File(t *testing.T) {
+	text := "[ACH_WIN]\r\nAchieved=1\r\n"
+	data := append([]byte(nil), bomUTF16LE...)
+	for _, r := range text {
+		data = append(data, byte(r), 0)
+	}
+
+	result, err := Parse(bytes.NewReader(data), "achievements.ini")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if result.Encoding != EncodingUTF16LEBOM || !result.Achievements["ACH_WIN"].Achieved {
+		t.Errorf("got %s %+v", result.Encoding, result.Achievements)
+	}
+}
+
+func TestParseTruncatedUTF16File(t *testing.T) {
+	var data []byte
+	for _, r := range "[ACH_WIN]\nAchieved=1\n" {
+		data = append(data, byte(r), 0)
+	}
+	data = append(data, 'x')
+
+	// With a BOM the encoding is known, and the half character is dropped.
+	result, err := Parse(bytes.NewReader(append(append([]byte(nil), bomUTF16LE...), data...)), "achievements.ini")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !result.Achievements["ACH_WIN"].Achieved {
+		t.Errorf("got %+v", result.Achievements)
+	}
+
+	// Without one, an odd length means it isn't UTF-16 after all.
+	if _, err := Parse(bytes.NewReader(data), "achievements.ini"); err == nil {
+		t.Error("expected an error for a truncated UTF-16 file without a BOM")
+	}
+}
@@ -0,0 +1,206 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// goldbergEntry is a single achievement as written by the Goldberg emulator.
+// Newer builds key entries by API name, older ones write an array of entries
+// that carry the name themselves.
+type goldbergEntry struct {
+	Name     string    `json:"name"`
+	Earned   *flexBool `json:"earned"`
+	Achieved *flexBool `json:"achieved"`
+
+	EarnedTime int64 `json:"earned_time"`
+	UnlockTime int64 `json:"unlock_time"`
+
+	Progress    float64 `json:"progress"`
+	MaxProgress float64 `json:"max_progress"`
+}
+
+func (e goldbergEntry) achieved() bool {
+	if e.Earned != nil {
+		return bool(*e.Earned)
+	}
+	if e.Achieved != nil {
+		return bool(*e.Achieved)
+	}
+	return false
+}
+
+func (e goldbergEntry) toAchievement(name string) Achievement {
+	return Achievement{
+		Name:        name,
+		Achieved:    e.achieved(),
+		UnlockTime:  e.unlockTime(),
+		CurProgress: int(e.Progress),
+		MaxProgress: int(e.MaxProgress),
+	}
+}
+
+func (e goldbergEntry) unlockTime() time.Time {
+	if e.EarnedTime != 0 {
+		return parseUnixTime(e.EarnedTime)
+	}
+	return parseUnixTime(e.UnlockTime)
+}
+
+// flexBool accepts JSON booleans as well as the numeric and string spellings
+// some emulator builds write instead.
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(strings.TrimSpace(string(data)), `"`)
+	if s == "" || s == "null" {
+		*b = false
+		return nil
+	}
+	if v, err := strconv.ParseBool(s); err == nil {
+		*b = flexBool(v)
+		return nil
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		*b = n != 0
+		return nil
+	}
+	return fmt.Errorf("invalid boolean value: %s", s)
+}
+
+func parseGoldberg(data []byte) (*Result, error) {
+	achievements := make(map[string]Achievement)
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var entries []goldbergEntry
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, fmt.Errorf("error parsing Goldberg achievements: %v", err)
+		}
+		for _, e := range entries {
+			if !shouldIncludeAchievement(e.Name) {
+				continue
+			}
+			achievements[e.Name] = e.toAchievement(e.Name)
+		}
+		return &Result{Dialect: DialectGoldbergLegacy, Achievements: achievements}, nil
+	}
+
+	var entries map[string]goldbergEntry
+	if err := json.Unmarshal(trimmed, &entries); err != nil {
+		return nil, fmt.Errorf("error parsing Goldberg achievements: %v", err)
+	}
+	for key, e := range entries {
+		name := key
+		if e.Name != "" {
+			name = e.Name
+		}
+		if !shouldIncludeAchievement(name) {
+			continue
+		}
+		achievements[name] = e.toAchievement(name)
+	}
+	return &Result{Dialect: DialectGoldberg, Achievements: achievements}, nil
+}
+
+func encodeGoldberg(original []byte, dialect string, achievements map[string]Achievement) ([]byte, error) {
+	trimmed := bytes.TrimSpace(original)
+	if dialect == DialectGoldbergLegacy || (len(trimmed) > 0 && trimmed[0] == '[') {
+		return encodeGoldbergLegacy(original, achievements)
+	}
+
+	root := newOrderedObject()
+	if len(trimmed) > 0 {
+		if err := json.Unmarshal(trimmed, root); err != nil {
+			return nil, fmt.Errorf("error parsing Goldberg achievements: %v", err)
+		}
+	}
+
+	for _, name := range sortedNames(achievements) {
+		entry := newOrderedObject()
+		if raw, ok := root.values[name]; ok {
+			if err := json.Unmarshal(raw, entry); err != nil {
+				return nil, fmt.Errorf("error parsing Goldberg achievement %s: %v", name, err)
+			}
+		}
+		if err := updateGoldbergEntry(entry, achievements[name]); err != nil {
+			return nil, err
+		}
+		if err := root.set(name, entry); err != nil {
+			return nil, err
+		}
+	}
+
+	return marshalLike(root, original, "    ")
+}
+
+func encodeGoldbergLegacy(original []byte, achievements map[string]Achievement) ([]byte, error) {
+	var entries []*orderedObject
+	if trimmed := bytes.TrimSpace(original); len(trimmed) > 0 {
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, fmt.Errorf("error parsing Goldberg achievements: %v", err)
+		}
+	}
+
+	byName := make(map[string]*orderedObject)
+	for _, entry := range entries {
+		var name string
+		if raw, ok := entry.values["name"]; ok && json.Unmarshal(raw, &name) == nil {
+			byName[name] = entry
+		}
+	}
+
+	for _, name := range sortedNames(achievements) {
+		entry, ok := byName[name]
+		if !ok {
+			entry = newOrderedObject()
+			if err := entry.set("name", name); err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		}
+		if err := updateGoldbergEntry(entry, achievements[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	return marshalLike(entries, original, "    ")
+}
+
+// updateGoldbergEntry writes a's state into entry, reusing whichever key
+// spellings the entry already has.
+func updateGoldbergEntry(entry *orderedObject, a Achievement) error {
+	achievedKey := "earned"
+	if !entry.has("earned") && entry.has("achieved") {
+		achievedKey = "achieved"
+	}
+	timeKey := "earned_time"
+	if !entry.has("earned_time") && entry.has("unlock_time") {
+		timeKey = "unlock_time"
+	}
+
+	var unlockTime int64
+	if !a.UnlockTime.IsZero() {
+		unlockTime = a.UnlockTime.Unix()
+	}
+
+	if err := entry.set(achievedKey, a.Achieved); err != nil {
+		return err
+	}
+	if err := entry.set(timeKey, unlockTime); err != nil {
+		return err
+	}
+	if a.MaxProgress > 0 || entry.has("max_progress") {
+		if err := entry.set("progress", a.CurProgress); err != nil {
+			return err
+		}
+		if err := entry.set("max_progress", a.MaxProgress); err != nil {
+			return err
+		}
+	}
+	return nil
+}
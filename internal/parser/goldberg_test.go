@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseGoldberg(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		dialect string
+		want    map[string]Achievement
+	}{
+		{
+			name: "keyed by API name",
+			data: `{
+				"ACH_WIN": {"earned": true, "earned_time": 1700000000},
+				"ACH_LOSE": {"earned": false, "earned_time": 0}
+			}`,
+			dialect: DialectGoldberg,
+			want: map[string]Achievement{
+				"ACH_WIN":  {Name: "ACH_WIN", Achieved: true, UnlockTime: time.Unix(1700000000, 0)},
+				"ACH_LOSE": {Name: "ACH_LOSE"},
+			},
+		},
+		{
+			name:    "legacy array",
+			data:    `[{"name": "ACH_WIN", "achieved": 1, "unlock_time": 1700000000}, {"name": "", "achieved": 1}]`,
+			dialect: DialectGoldbergLegacy,
+			want: map[string]Achievement{
+				"ACH_WIN": {Name: "ACH_WIN", Achieved: true, UnlockTime: time.Unix(1700000000, 0)},
+			},
+		},
+		{
+			name:    "string booleans and progress",
+			data:    `{"ACH_GRIND": {"earned": "false", "progress": 30, "max_progress": 100}}`,
+			dialect: DialectGoldberg,
+			want: map[string]Achievement{
+				"ACH_GRIND": {Name: "ACH_GRIND", CurProgress: 30, MaxProgress: 100},
+			},
+		},
+		{
+			name:    "millisecond unlock time",
+			data:    `{"ACH_WIN": {"earned": true, "earned_time": 1700000000123}}`,
+			dialect: DialectGoldberg,
+			want: map[string]Achievement{
+				"ACH_WIN": {Name: "ACH_WIN", Achieved: true, UnlockTime: time.UnixMilli(1700000000123)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Parse(strings.NewReader(tt.data), "achievements.json")
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if result.Format != FormatJSON || result.Dialect != tt.dialect {
+				t.Errorf("format %s/%s, want %s/%s", result.Format, result.Dialect, FormatJSON, tt.dialect)
+			}
+			assertAchievements(t, result.Achievements, tt.want)
+		})
+	}
+}
+
+func TestParseGoldbergMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"truncated object", `{"ACH_WIN": {"earned": true`},
+		{"invalid boolean", `{"ACH_WIN": {"earned": "maybe"}}`},
+		{"entry is not an object", `{"ACH_WIN": 1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseJSON(&Input{Path: "achievements.json", Data: []byte(tt.data)}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+// assertAchievements compares parsed achievements with the expected ones,
+// ignoring Info.
+func assertAchievements(t *testing.T, got, want map[string]Achievement) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("got %d achievements, want %d: %+v", len(got), len(want), got)
+	}
+	for name, w := range want {
+		g, ok := got[name]
+		if !ok {
+			t.Errorf("missing achievement %s", name)
+			continue
+		}
+		g.Info = nil
+		w.Info = nil
+		if g.Name != w.Name || g.Achieved != w.Achieved || !g.UnlockTime.Equal(w.UnlockTime) ||
+			g.CurProgress != w.CurProgress || g.MaxProgress != w.MaxProgress {
+			t.Errorf("achievement %s = %+v, want %+v", name, g, w)
+		}
+	}
+}
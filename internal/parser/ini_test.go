@@ -0,0 +1,168 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseINI(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want map[string]Achievement
+	}{
+		{
+			name: "CODEX",
+			data: "[SteamAchievements]\r\n00000=ACH_WIN\r\nCount=1\r\n\r\n[ACH_WIN]\r\nAchieved=1\r\nCurProgress=0\r\nMaxProgress=0\r\nUnlockTime=1700000000\r\n",
+			want: map[string]Achievement{
+				"ACH_WIN": {Name: "ACH_WIN", Achieved: true, UnlockTime: time.Unix(1700000000, 0)},
+			},
+		},
+		{
+			name: "progress",
+			data: "[ACH_GRIND]\nAchieved=0\nCurProgress=30\nMaxProgress=100\nUnlockTime=0\n",
+			want: map[string]Achievement{
+				"ACH_GRIND": {Name: "ACH_GRIND", CurProgress: 30, MaxProgress: 100},
+			},
+		},
+		{
+			name: "OnlineFix",
+			data: "[ACH_WIN]\nachieved=true\ntimestamp=1700000000\n",
+			want: map[string]Achievement{
+				"ACH_WIN": {Name: "ACH_WIN", Achieved: true, UnlockTime: time.Unix(1700000000, 0)},
+			},
+		},
+		{
+			name: "stats section",
+			data: "[ACH_WIN]\nAchieved=1\n[Stats]\nkills=12\n",
+			want: map[string]Achievement{
+				"ACH_WIN": {Name: "ACH_WIN", Achieved: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseINI(&Input{Path: "achievements.ini", Data: []byte(tt.data)})
+			if err != nil {
+				t.Fatalf("parseINI: %v", err)
+			}
+			assertAchievements(t, result.Achievements, tt.want)
+		})
+	}
+}
+
+func TestParseINIStats(t *testing.T) {
+	result, err := parseINI(&Input{Path: "stats.ini", Data: []byte("[Stats]\nkills=12\naccuracy=0.5\n")})
+	if err != nil {
+		t.Fatalf("parseINI: %v", err)
+	}
+	if result.Stats["kills"].Value != 12 || result.Stats["accuracy"].Value != 0.5 {
+		t.Errorf("stats = %+v", result.Stats)
+	}
+}
+
+func TestParseINIUnlockTimes(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want time.Time
+	}{
+		{"CODEX", "[ACH_WIN]\nAchieved=1\nUnlockTime=1700000000\n", time.Unix(1700000000, 0)},
+		{"OnlineFix", "[ACH_WIN]\nachieved=true\ntimestamp=1700000000\n", time.Unix(1700000000, 0)},
+		{"ALI213", "[ACH_WIN]\nHaveAchieved=1\nHaveAchievedTime=1700000000\n", time.Unix(1700000000, 0)},
+		{"milliseconds", "[ACH_WIN]\nAchieved=1\nearned_time=1700000000123\n", time.UnixMilli(1700000000123)},
+		{"generic time key", "[ACH_WIN]\nAchieved=1\ntime=1700000000\n", time.Time{}},
+		{"zero", "[ACH_WIN]\nAchieved=1\nUnlockTime=0\n", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseINI(&Input{Path: "achievements.ini", Data: []byte(tt.data)})
+			if err != nil {
+				t.Fatalf("parseINI: %v", err)
+			}
+			if got := result.Achievements["ACH_WIN"].UnlockTime; !got.Equal(tt.want) {
+				t.Errorf("unlock time = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseINIInvalidUnlockTime(t *testing.T) {
+	data := []byte("[ACH_WIN]\nAchieved=1\nUnlockTime=yesterday\n[ACH_LOSE]\nAchieved=0\n")
+	for _, lenient := range []bool{false, true} {
+		result, err := parseINI(&Input{Path: "achievements.ini", Data: data, Options: Options{Lenient: lenient}})
+		if err != nil {
+			t.Fatalf("lenient %v: an invalid unlock time should not fail the file: %v", lenient, err)
+		}
+		if len(result.Warnings) != 1 || result.Warnings[0].Line != 3 {
+			t.Errorf("lenient %v: warnings = %v", lenient, result.Warnings)
+		}
+		if win := result.Achievements["ACH_WIN"]; !win.Achieved || !win.UnlockTime.IsZero() || len(result.Achievements) != 2 {
+			t.Errorf("lenient %v: achievements = %+v", lenient, result.Achievements)
+		}
+	}
+}
+
+func TestParseINIMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		// kept are the achievements lenient mode still reads.
+		kept []string
+	}{
+		{"bad boolean", "[ACH_WIN]\nAchieved=maybe\n[ACH_LOSE]\nAchieved=0\n", []string{"ACH_LOSE"}},
+		{"bad progress", "[ACH_WIN]\nAchieved=0\nCurProgress=half\n", nil},
+		{"bad stat", "[ACH_WIN]\nAchieved=1\n[Stats]\nkills=lots\n", []string{"ACH_WIN"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseINI(&Input{Path: "achievements.ini", Data: []byte(tt.data)}); err == nil {
+				t.Error("expected an error in strict mode")
+			}
+
+			result, err := parseINI(&Input{Path: "achievements.ini", Data: []byte(tt.data), Options: Options{Lenient: true}})
+			if err != nil {
+				t.Fatalf("lenient parseINI: %v", err)
+			}
+			if len(result.Warnings) != 1 {
+				t.Errorf("got %d warnings, want 1: %v", len(result.Warnings), result.Warnings)
+			}
+			if len(result.Achievements) != len(tt.kept) {
+				t.Errorf("kept %d achievements, want %v", len(result.Achievements), tt.kept)
+			}
+			for _, name := range tt.kept {
+				if _, ok := result.Achievements[name]; !ok {
+					t.Errorf("lenient mode dropped %s", name)
+				}
+			}
+		})
+	}
+}
+
+func TestParseBool(t *testing.T) {
+	tests := []struct {
+		value   string
+		lenient bool
+		want    bool
+		wantErr bool
+	}{
+		{"1", false, true, false},
+		{"false", false, false, false},
+		{"yes", false, false, true},
+		{"yes", true, true, false},
+		{"off", true, false, false},
+		{"0x1", true, true, false},
+		{"", true, false, false},
+		{"maybe", true, false, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseBool(tt.value, tt.lenient)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseBool(%q, %v) = %v, %v; want %v, error %v", tt.value, tt.lenient, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// iniKeys are the key spellings a dialect uses when a key has to be added
+// to a file. Empty progress keys mean the dialect doesn't store progress.
+type iniKeys struct {
+	achieved    string
+	unlockTime  string
+	curProgress string
+	maxProgress string
+	trueValue   string
+	falseValue  string
+}
+
+var iniDialectKeys = map[string]iniKeys{
+	DialectCodex:     {"Achieved", "UnlockTime", "CurProgress", "MaxProgress", "1", "0"},
+	DialectOnlineFix: {"achieved", "timestamp", "", "", "true", "false"},
+	DialectAli213:    {"HaveAchieved", "HaveAchievedTime", "", "", "1", "0"},
+	DialectGeneric:   {"Achieved", "UnlockTime", "CurProgress", "MaxProgress", "1", "0"},
+}
+
+// iniSection is a run of raw lines starting with a section header. The
+// section before the first header has an empty name and no header line.
+// start is the line number of its first line in the parsed text.
+type iniSection struct {
+	name  string
+	lines []string
+	start int
+}
+
+type iniDocument struct {
+	sections []*iniSection
+	newline  string
+}
+
+func parseINIDocument(text string) *iniDocument {
+	doc := &iniDocument{newline: "\n"}
+	if strings.Contains(text, "\r\n") {
+		doc.newline = "\r\n"
+	}
+
+	current := &iniSection{start: 1}
+	doc.sections = append(doc.sections, current)
+
+	text = strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if text == "" {
+		return doc
+	}
+	for i, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			current = &iniSection{name: strings.Trim(trimmed, "[]"), start: i + 1}
+			doc.sections = append(doc.sections, current)
+		}
+		current.lines = append(current.lines, line)
+	}
+	return doc
+}
+
+func (d *iniDocument) section(name string) *iniSection {
+	for _, s := range d.sections {
+		if s.name == name && len(s.lines) > 0 {
+			return s
+		}
+	}
+	return nil
+}
+
+func (d *iniDocument) String() string {
+	var b strings.Builder
+	for _, s := range d.sections {
+		for _, line := range s.lines {
+			b.WriteString(line)
+			b.WriteString(d.newline)
+		}
+	}
+	return b.String()
+}
+
+// find returns the index of the first line whose key, lowercased, is one of
+// keys, or -1.
+func (s *iniSection) find(keys ...string) int {
+	for i, line := range s.lines {
+		k, _, ok := strings.Cut(line, "=")
+		if ok && slices.Contains(keys, strings.ToLower(strings.TrimSpace(k))) {
+			return i
+		}
+	}
+	return -1
+}
+
+// lineNumber returns the line number of line i in the parsed text. It only
+// holds until lines are added to the document.
+func (s *iniSection) lineNumber(i int) int {
+	return s.start + i
+}
+
+func (s *iniSection) value(i int) string {
+	_, v, _ := strings.Cut(s.lines[i], "=")
+	return strings.TrimSpace(v)
+}
+
+// set replaces the value on line i, keeping the key and the spacing around
+// the equals sign as written.
+func (s *iniSection) set(i int, value string) {
+	eq := strings.Index(s.lines[i], "=")
+	rest := s.lines[i][eq+1:]
+	pad := rest[:len(rest)-len(strings.TrimLeft(rest, " \t"))]
+	s.lines[i] = s.lines[i][:eq+1] + pad + value
+}
+
+// add appends key=value after the section's last non-blank line.
+func (s *iniSection) add(key, value string) {
+	at := len(s.lines)
+	for at > 1 && strings.TrimSpace(s.lines[at-1]) == "" {
+		at--
+	}
+	s.lines = slices.Insert(s.lines, at, key+"="+value)
+}
+
+// setOrAdd updates the first existing key out of aliases or, failing that,
+// adds key.
+func (s *iniSection) setOrAdd(aliases []string, key, value string) {
+	if i := s.find(aliases...); i >= 0 {
+		s.set(i, value)
+	} else if key != "" {
+		s.add(key, value)
+	}
+}
+
+func encodeINI(original []byte, dialect string, achievements map[string]Achievement) ([]byte, error) {
+	keys, ok := iniDialectKeys[dialect]
+	if !ok {
+		keys = iniDialectKeys[DialectGeneric]
+	}
+
+	doc := parseINIDocument(string(original))
+	for _, name := range sortedNames(achievements) {
+		a := achievements[name]
+		if strings.ContainsAny(name, "[]\r\n") {
+			return nil, fmt.Errorf("invalid achievement name for INI: %q", name)
+		}
+
+		section := doc.section(name)
+		if section == nil {
+			section = doc.appendSection(name)
+			doc.addToIndex(name)
+		}
+
+		achieved := keys.falseValue
+		if i := section.find(iniAchievedKeys...); i >= 0 {
+			achieved = formatINIBool(a.Achieved, section.value(i))
+			section.set(i, achieved)
+		} else {
+			if a.Achieved {
+				achieved = keys.trueValue
+			}
+			section.add(keys.achieved, achieved)
+		}
+
+		if i := section.find("curprogress"); i >= 0 || (a.MaxProgress > 0 && keys.curProgress != "") {
+			section.setOrAdd([]string{"curprogress"}, keys.curProgress, strconv.Itoa(a.CurProgress))
+			section.setOrAdd([]string{"maxprogress"}, keys.maxProgress, strconv.Itoa(a.MaxProgress))
+		}
+
+		section.setOrAdd(iniUnlockTimeKeys, keys.unlockTime, formatUnixTime(a.UnlockTime))
+	}
+
+	return []byte(doc.String()), nil
+}
+
+// appendSection adds a new, empty section at the end of the document,
+// separated by a blank line if the document separates its sections that way.
+func (d *iniDocument) appendSection(name string) *iniSection {
+	last := d.sections[len(d.sections)-1]
+	if n := len(last.lines); n > 0 && strings.TrimSpace(last.lines[n-1]) != "" {
+		for _, s := range d.sections[:len(d.sections)-1] {
+			if n := len(s.lines); n > 0 && strings.TrimSpace(s.lines[n-1]) == "" {
+				last.lines = append(last.lines, "")
+				break
+			}
+		}
+	}
+
+	section := &iniSection{name: name, lines: []string{"[" + name + "]"}}
+	d.sections = append(d.sections, section)
+	return section
+}
+
+// addToIndex lists a new achievement in the [SteamAchievements] index that
+// CODEX-style files keep, if the file has one.
+func (d *iniDocument) addToIndex(name string) {
+	var index *iniSection
+	for _, s := range d.sections {
+		if strings.EqualFold(s.name, "steamachievements") {
+			index = s
+		}
+	}
+	if index == nil {
+		return
+	}
+
+	count := 0
+	for _, line := range index.lines {
+		k, _, ok := strings.Cut(line, "=")
+		if _, err := strconv.Atoi(strings.TrimSpace(k)); ok && err == nil {
+			count++
+		}
+	}
+	index.add(fmt.Sprintf("%05d", count), name)
+	if i := index.find("count"); i >= 0 {
+		index.set(i, strconv.Itoa(count+1))
+	}
+}
+
+// formatINIBool writes b in the same style as the value it replaces.
+func formatINIBool(b bool, previous string) string {
+	switch strings.ToLower(previous) {
+	case "true", "false":
+		return strconv.FormatBool(b)
+	}
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func formatUnixTime(t time.Time) string {
+	if t.IsZero() {
+		return "0"
+	}
+	return strconv.FormatInt(t.Unix(), 10)
+}
@@ -0,0 +1,120 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// orderedObject is a JSON object that remembers the order of its keys, so a
+// file can be rewritten without reshuffling it or dropping keys we don't
+// understand.
+type orderedObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+func newOrderedObject() *orderedObject {
+	return &orderedObject{values: make(map[string]json.RawMessage)}
+}
+
+func (o *orderedObject) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("expected JSON object")
+	}
+
+	o.keys = nil
+	o.values = make(map[string]json.RawMessage)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key := token.(string)
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return err
+		}
+		if _, exists := o.values[key]; !exists {
+			o.keys = append(o.keys, key)
+		}
+		o.values[key] = value
+	}
+	_, err = decoder.Token()
+	return err
+}
+
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(o.values[key])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (o *orderedObject) has(key string) bool {
+	_, ok := o.values[key]
+	return ok
+}
+
+// set stores value under key, keeping the key's position if it exists and
+// appending it otherwise.
+func (o *orderedObject) set(key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if !o.has(key) {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = raw
+	return nil
+}
+
+// marshalLike encodes v with the same indentation and trailing newline as
+// original. A new document (nil original) is indented with defaultIndent.
+func marshalLike(v any, original []byte, defaultIndent string) ([]byte, error) {
+	indent := defaultIndent
+	if original != nil {
+		indent = ""
+		if lines := bytes.Split(bytes.TrimSpace(original), []byte("\n")); len(lines) > 1 {
+			line := lines[1]
+			indent = string(line[:len(line)-len(bytes.TrimLeft(line, " \t"))])
+		}
+	}
+
+	var data []byte
+	var err error
+	if indent == "" {
+		data, err = json.Marshal(v)
+	} else {
+		data, err = json.MarshalIndent(v, "", indent)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if original == nil || bytes.HasSuffix(original, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	if bytes.Contains(original, []byte("\r\n")) {
+		data = bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n"))
+	}
+	return data, nil
+}
@@ -0,0 +1,112 @@
+package parser
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatLumaPlay  = "lumaplay"
+	DialectLumaPlay = "lumaplay"
+)
+
+// LumaPlayRoot is the folder under which LumaPlay keeps its saves, one
+// folder per account and one per Ubisoft game ID inside that.
+const LumaPlayRoot = "LumaPlay"
+
+func init() {
+	Register(Format{
+		Name: FormatLumaPlay,
+		Match: func(path string) bool {
+			return strings.EqualFold(filepath.Base(path), "achievements.ini") && underFolder(path, LumaPlayRoot)
+		},
+		Sniff:  sniffINI,
+		Decode: parseLumaPlay,
+		Encode: encodeLumaPlay,
+	})
+}
+
+// parseLumaPlay decodes a LumaPlay achievements.ini. Each achievement has a
+// section named by its Ubisoft ID holding its state and its own name,
+// description and icon; the [Game] section names the game.
+func parseLumaPlay(in *Input) (*Result, error) {
+	doc := parseINIDocument(string(in.Data))
+	result := &Result{Dialect: DialectLumaPlay, Achievements: make(map[string]Achievement)}
+
+	gameName := ""
+	if game := doc.section("Game"); game != nil {
+		if i := game.find("name"); i >= 0 {
+			gameName = game.value(i)
+		}
+	}
+
+	for _, s := range doc.sections {
+		if s.name == "" || strings.EqualFold(s.name, "game") || !shouldIncludeAchievement(s.name) {
+			continue
+		}
+		a := Achievement{Name: s.name}
+		if i := s.find(iniAchievedKeys...); i >= 0 {
+			achieved, err := parseBool(s.value(i), in.Lenient)
+			if err != nil {
+				if !in.Lenient {
+					return nil, fmt.Errorf("error parsing LumaPlay achievement %s: %v", s.name, err)
+				}
+				result.Warnings = append(result.Warnings, Warning{Line: s.lineNumber(i), Section: s.name, Message: err.Error()})
+				continue
+			}
+			a.Achieved = achieved
+		}
+		if i := s.find(iniUnlockTimeKeys...); i >= 0 && a.Achieved {
+			t, err := strconv.ParseInt(s.value(i), 10, 64)
+			if err != nil {
+				message := fmt.Sprintf("ignoring invalid unlock time: %v", err)
+				result.Warnings = append(result.Warnings, Warning{Line: s.lineNumber(i), Section: s.name, Message: message})
+			} else {
+				a.UnlockTime = parseUnixTime(t)
+			}
+		}
+
+		info := &Info{GameName: gameName}
+		if i := s.find("name"); i >= 0 {
+			info.DisplayName = s.value(i)
+		}
+		if i := s.find("description"); i >= 0 {
+			info.Description = s.value(i)
+		}
+		if i := s.find("icon"); i >= 0 && s.value(i) != "" {
+			info.Icon = filepath.Join(filepath.Dir(in.Path), s.value(i))
+		}
+		if info.DisplayName != "" {
+			a.Info = info
+		}
+		result.Achievements[s.name] = a
+	}
+	return result, nil
+}
+
+// encodeLumaPlay writes achievement states into a LumaPlay achievements.ini,
+// keeping each section's name, description and icon as they are. New
+// achievements get a section with only their state.
+func encodeLumaPlay(original []byte, dialect string, achievements map[string]Achievement) ([]byte, error) {
+	doc := parseINIDocument(string(original))
+	for _, name := range sortedNames(achievements) {
+		a := achievements[name]
+		if strings.ContainsAny(name, "[]\r\n") || strings.EqualFold(name, "game") {
+			return nil, fmt.Errorf("invalid achievement name for LumaPlay: %q", name)
+		}
+
+		section := doc.section(name)
+		if section == nil {
+			section = doc.appendSection(name)
+		}
+		if i := section.find(iniAchievedKeys...); i >= 0 {
+			section.set(i, formatINIBool(a.Achieved, section.value(i)))
+		} else {
+			section.add("achieved", formatINIBool(a.Achieved, ""))
+		}
+		section.setOrAdd(iniUnlockTimeKeys, "timestamp", formatUnixTime(a.UnlockTime))
+	}
+	return []byte(doc.String()), nil
+}
@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const lumaPlayFixture = `[Game]
+name=Test Game
+
+[1]
+achieved=1
+timestamp=1700000000
+name=Winner
+description=Win a match
+icon=images\1.png
+
+[2]
+achieved=0
+timestamp=0
+name=Loser
+description=Lose a match
+
+[3]
+achieved=1
+`
+
+func TestParseLumaPlay(t *testing.T) {
+	path := filepath.Join("saves", LumaPlayRoot, "account", "46", "achievements.ini")
+	if got := DetectFormat([]byte(lumaPlayFixture), path); got != FormatLumaPlay {
+		t.Fatalf("DetectFormat = %q", got)
+	}
+
+	result, err := parseLumaPlay(&Input{Path: path, Data: []byte(lumaPlayFixture)})
+	if err != nil {
+		t.Fatalf("parseLumaPlay: %v", err)
+	}
+	assertAchievements(t, result.Achievements, map[string]Achievement{
+		"1": {Name: "1", Achieved: true, UnlockTime: time.Unix(1700000000, 0)},
+		"2": {Name: "2"},
+		"3": {Name: "3", Achieved: true},
+	})
+
+	info := result.Achievements["1"].Info
+	if info == nil || info.DisplayName != "Winner" || info.Description != "Win a match" || info.GameName != "Test Game" ||
+		info.Icon != filepath.Join(filepath.Dir(path), `images\1.png`) {
+		t.Errorf("achievement 1 info = %+v", info)
+	}
+	if result.Achievements["3"].Info != nil {
+		t.Error("achievement 3 has no name and should have no info")
+	}
+}
+
+func TestParseLumaPlayMalformed(t *testing.T) {
+	path := filepath.Join(LumaPlayRoot, "account", "46", "achievements.ini")
+	data := []byte("[1]\nachieved=maybe\nname=Winner\n[2]\nachieved=1\nname=Other\n")
+
+	if _, err := parseLumaPlay(&Input{Path: path, Data: data}); err == nil {
+		t.Error("expected an error in strict mode")
+	}
+
+	result, err := parseLumaPlay(&Input{Path: path, Data: data, Options: Options{Lenient: true}})
+	if err != nil {
+		t.Fatalf("lenient parseLumaPlay: %v", err)
+	}
+	if len(result.Warnings) != 1 || len(result.Achievements) != 1 || !result.Achievements["2"].Achieved {
+		t.Errorf("lenient result = %+v", result)
+	}
+	if w := result.Warnings[0]; w.Line != 2 || w.Section != "1" {
+		t.Errorf("warning = %v, want line 2 of section 1", w)
+	}
+}
+
+func TestParseLumaPlayInvalidUnlockTime(t *testing.T) {
+	path := filepath.Join(LumaPlayRoot, "account", "46", "achievements.ini")
+	data := []byte("[Game]\r\nname=Test Game\r\n\r\n[1]\r\nachieved=1\r\nname=Winner\r\ntimestamp=yesterday\r\n")
+
+	for _, lenient := range []bool{false, true} {
+		result, err := parseLumaPlay(&Input{Path: path, Data: data, Options: Options{Lenient: lenient}})
+		if err != nil {
+			t.Fatalf("lenient %v: an invalid unlock time should not fail the file: %v", lenient, err)
+		}
+		if len(result.Warnings) != 1 || result.Warnings[0].Line != 7 {
+			t.Errorf("lenient %v: warnings = %v", lenient, result.Warnings)
+		}
+		if win := result.Achievements["1"]; !win.Achieved || !win.UnlockTime.IsZero() {
+			t.Errorf("lenient %v: achievement 1 = %+v", lenient, win)
+		}
+	}
+}
+
+func TestSetAchievedLumaPlay(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), LumaPlayRoot, "account", "46")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "achievements.ini")
+	data := "[Game]\nname=Test Game\n\n[1]\nachieved=1\ntimestamp=1700000000\nname=Winner\n\n" +
+		"[2]\nachieved=0\ntimestamp=0\nname=Loser\ndescription=Lose a match\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetAchieved(path, "2", true, time.Unix(1700000500, 0)); err != nil {
+		t.Fatalf("SetAchieved: %v", err)
+	}
+	if err := SetAchieved(path, "3", true, time.Unix(1700000600, 0)); err != nil {
+		t.Fatalf("SetAchieved: %v", err)
+	}
+
+	want := "[Game]\nname=Test Game\n\n[1]\nachieved=1\ntimestamp=1700000000\nname=Winner\n\n" +
+		"[2]\nachieved=1\ntimestamp=1700000500\nname=Loser\ndescription=Lose a match\n\n" +
+		"[3]\nachieved=1\ntimestamp=1700000600\n"
+	if got, _ := os.ReadFile(path); string(got) != want {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+
+	if _, err := encodeLumaPlay(nil, DialectLumaPlay, map[string]Achievement{"Game": {Name: "Game"}}); err == nil {
+		t.Error("an achievement named Game should be rejected")
+	}
+}
+
+func TestLumaPlayNeedsItsFolder(t *testing.T) {
+	path := filepath.Join("saves", "46", "achievements.ini")
+	if got := DetectFormat([]byte(lumaPlayFixture), path); got != FormatINI {
+		t.Errorf("DetectFormat outside the LumaPlay folder = %q, want %q", got, FormatINI)
+	}
+}
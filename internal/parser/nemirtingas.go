@@ -0,0 +1,195 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	FormatNemirtingas  = "nemirtingas"
+	DialectNemirtingas = "nemirtingas"
+)
+
+// NemirtingasRoot is the folder under which the Nemirtingas Epic emulator
+// keeps its saves, one folder per account and one per game namespace
+// inside that.
+const NemirtingasRoot = "NemirtingasEpicEmu"
+
+func init() {
+	Register(Format{
+		Name: FormatNemirtingas,
+		Match: func(path string) bool {
+			return strings.EqualFold(filepath.Base(path), "achievements.json") && underFolder(path, NemirtingasRoot)
+		},
+		Sniff:  sniffNemirtingas,
+		Decode: parseNemirtingas,
+		Encode: encodeNemirtingas,
+	})
+}
+
+type nemirtingasEntry struct {
+	ID         string    `json:"achievement_id"`
+	Unlocked   *flexBool `json:"unlocked"`
+	UnlockTime int64     `json:"unlock_time"`
+	Progress   float64   `json:"progress"`
+}
+
+// nemirtingasInfo is an entry of the emulator's achievements_db.json.
+type nemirtingasInfo struct {
+	ID                  string   `json:"achievement_id"`
+	UnlockedDisplayName string   `json:"unlocked_display_name"`
+	UnlockedDescription string   `json:"unlocked_description"`
+	LockedDisplayName   string   `json:"locked_display_name"`
+	LockedDescription   string   `json:"locked_description"`
+	UnlockedIconURL     string   `json:"unlocked_icon_url"`
+	Hidden              flexBool `json:"is_hidden"`
+}
+
+// sniffNemirtingas recognises the emulator's achievements.json, a JSON
+// array of entries keyed by achievement_id rather than Goldberg's name.
+func sniffNemirtingas(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		return false
+	}
+	var entries []map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &entries); err != nil || len(entries) == 0 {
+		return false
+	}
+	_, ok := entries[0]["achievement_id"]
+	return ok
+}
+
+// parseNemirtingas decodes a Nemirtingas Epic emulator achievements.json.
+// Progress is stored as a fraction, which is reported out of 100. Names and
+// descriptions come from achievements_db.json in the same folder, if any.
+func parseNemirtingas(in *Input) (*Result, error) {
+	var entries []nemirtingasEntry
+	if err := json.Unmarshal(bytes.TrimSpace(in.Data), &entries); err != nil {
+		return nil, fmt.Errorf("error parsing Nemirtingas achievements: %v", err)
+	}
+	infos := readNemirtingasDB(filepath.Join(filepath.Dir(in.Path), "achievements_db.json"))
+
+	achievements := make(map[string]Achievement)
+	for _, e := range entries {
+		if !shouldIncludeAchievement(e.ID) {
+			continue
+		}
+		a := Achievement{Name: e.ID, UnlockTime: parseUnixTime(e.UnlockTime)}
+		if e.Unlocked != nil {
+			a.Achieved = bool(*e.Unlocked)
+		}
+		if e.Progress > 0 && e.Progress < 1 {
+			a.CurProgress = int(e.Progress * 100)
+			a.MaxProgress = 100
+		}
+		if info, ok := infos[e.ID]; ok {
+			a.Info = &Info{
+				DisplayName: firstNonEmpty(info.UnlockedDisplayName, info.LockedDisplayName),
+				Description: firstNonEmpty(info.UnlockedDescription, info.LockedDescription),
+				Icon:        info.UnlockedIconURL,
+				Hidden:      bool(info.Hidden),
+			}
+		}
+		achievements[e.ID] = a
+	}
+	return &Result{Dialect: DialectNemirtingas, Achievements: achievements}, nil
+}
+
+// encodeNemirtingas updates the entries of an achievements.json in place,
+// adding entries for achievements it doesn't list yet. Progress is written
+// back as a fraction; achievements_db.json is left alone.
+func encodeNemirtingas(original []byte, dialect string, achievements map[string]Achievement) ([]byte, error) {
+	var entries []*orderedObject
+	if trimmed := bytes.TrimSpace(original); len(trimmed) > 0 {
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, fmt.Errorf("error parsing Nemirtingas achievements: %v", err)
+		}
+	}
+
+	byID := make(map[string]*orderedObject)
+	for _, entry := range entries {
+		var id string
+		if raw, ok := entry.values["achievement_id"]; ok && json.Unmarshal(raw, &id) == nil {
+			byID[id] = entry
+		}
+	}
+
+	for _, name := range sortedNames(achievements) {
+		a := achievements[name]
+		entry, ok := byID[name]
+		if !ok {
+			entry = newOrderedObject()
+			if err := entry.set("achievement_id", name); err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		}
+
+		var unlockTime int64
+		if !a.UnlockTime.IsZero() {
+			unlockTime = a.UnlockTime.Unix()
+		}
+		progress := 0.0
+		if a.Achieved {
+			progress = 1
+		} else if a.MaxProgress > 0 {
+			progress = float64(a.CurProgress) / float64(a.MaxProgress)
+		}
+
+		if err := entry.set("unlocked", a.Achieved); err != nil {
+			return nil, err
+		}
+		if err := entry.set("unlock_time", unlockTime); err != nil {
+			return nil, err
+		}
+		if err := entry.set("progress", progress); err != nil {
+			return nil, err
+		}
+	}
+
+	return marshalLike(entries, original, "    ")
+}
+
+func readNemirtingasDB(path string) map[string]nemirtingasInfo {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var entries []nemirtingasInfo
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil
+	}
+	infos := make(map[string]nemirtingasInfo, len(entries))
+	for _, e := range entries {
+		infos[e.ID] = e
+	}
+	return infos
+}
+
+// underFolder reports whether any directory on path is named folder.
+func underFolder(path string, folder string) bool {
+	for _, part := range strings.FieldsFunc(filepath.Dir(path), isPathSeparator) {
+		if strings.EqualFold(part, folder) {
+			return true
+		}
+	}
+	return false
+}
+
+func isPathSeparator(r rune) bool {
+	return r == '/' || r == '\\'
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,168 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeNemirtingasFixture(t *testing.T, achievements string, db string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), NemirtingasRoot, "account", "fortnite")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if db != "" {
+		if err := os.WriteFile(filepath.Join(dir, "achievements_db.json"), []byte(db), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "achievements.json")
+	if err := os.WriteFile(path, []byte(achievements), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseNemirtingas(t *testing.T) {
+	path := writeNemirtingasFixture(t, `[
+		{"achievement_id": "win", "unlocked": true, "unlock_time": 1700000000, "progress": 1},
+		{"achievement_id": "grind", "unlocked": false, "unlock_time": 0, "progress": 0.25},
+		{"achievement_id": "", "unlocked": true}
+	]`, `[
+		{"achievement_id": "win", "unlocked_display_name": "Winner", "locked_description": "Win a match", "unlocked_icon_url": "https://example.com/win.png", "is_hidden": 1}
+	]`)
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	result, err := Parse(f, path)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if result.Format != FormatNemirtingas || result.Source != SourceNemirtingas {
+		t.Errorf("format %s, source %s", result.Format, result.Source)
+	}
+	assertAchievements(t, result.Achievements, map[string]Achievement{
+		"win":   {Name: "win", Achieved: true, UnlockTime: time.Unix(1700000000, 0)},
+		"grind": {Name: "grind", CurProgress: 25, MaxProgress: 100},
+	})
+	info := result.Achievements["win"].Info
+	if info == nil || info.DisplayName != "Winner" || info.Description != "Win a match" || !info.Hidden || info.Icon != "https://example.com/win.png" {
+		t.Errorf("win info = %+v", info)
+	}
+	if result.Achievements["grind"].Info != nil {
+		t.Error("grind has no entry in the database and should have no info")
+	}
+}
+
+func TestParseNemirtingasMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"truncated", `[{"achievement_id": "win", "unlocked": tr`},
+		{"object instead of array", `{"achievement_id": "win"}`},
+		{"bad unlocked value", `[{"achievement_id": "win", "unlocked": "maybe"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeNemirtingasFixture(t, tt.data, "")
+			if _, err := parseNemirtingas(&Input{Path: path, Data: []byte(tt.data)}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	// A broken database only costs the display names.
+	data := `[{"achievement_id": "win", "unlocked": true}]`
+	path := writeNemirtingasFixture(t, data, "{broken")
+	result, err := parseNemirtingas(&Input{Path: path, Data: []byte(data)})
+	if err != nil || result.Achievements["win"].Info != nil {
+		t.Errorf("with a broken database: %+v, %v", result, err)
+	}
+}
+
+func TestSetAchievedNemirtingas(t *testing.T) {
+	db := `[{"achievement_id": "win", "unlocked_display_name": "Winner"}]`
+	path := writeNemirtingasFixture(t, `[
+    {
+        "achievement_id": "win",
+        "unlocked": false,
+        "unlock_time": 0,
+        "progress": 0.25
+    }
+]
+`, db)
+
+	if err := SetAchieved(path, "win", true, time.Unix(1700000500, 0)); err != nil {
+		t.Fatalf("SetAchieved: %v", err)
+	}
+	if err := SetAchieved(path, "new", true, time.Unix(1700000600, 0)); err != nil {
+		t.Fatalf("SetAchieved: %v", err)
+	}
+
+	want := `[
+    {
+        "achievement_id": "win",
+        "unlocked": true,
+        "unlock_time": 1700000500,
+        "progress": 1
+    },
+    {
+        "achievement_id": "new",
+        "unlocked": true,
+        "unlock_time": 1700000600,
+        "progress": 1
+    }
+]
+`
+	if got, _ := os.ReadFile(path); string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if got, _ := os.ReadFile(filepath.Join(filepath.Dir(path), "achievements_db.json")); string(got) != db {
+		t.Errorf("achievements_db.json was changed to %s", got)
+	}
+}
+
+func TestEncodeNemirtingasProgress(t *testing.T) {
+	data, err := encodeNemirtingas(nil, DialectNemirtingas, map[string]Achievement{
+		"grind": {Name: "grind", CurProgress: 30, MaxProgress: 100},
+	})
+	if err != nil {
+		t.Fatalf("encodeNemirtingas: %v", err)
+	}
+	result, err := parseNemirtingas(&Input{Path: "achievements.json", Data: data})
+	if err != nil {
+		t.Fatalf("parseNemirtingas: %v", err)
+	}
+	if grind := result.Achievements["grind"]; grind.Achieved || grind.CurProgress != 30 || grind.MaxProgress != 100 {
+		t.Errorf("grind = %+v", grind)
+	}
+}
+
+func TestNemirtingasNeedsItsFolder(t *testing.T) {
+	data := []byte(`[{"achievement_id": "win", "unlocked": true}]`)
+	tests := []struct {
+		path string
+		want string
+	}{
+		{filepath.Join(NemirtingasRoot, "account", "game", "achievements.json"), FormatNemirtingas},
+		{filepath.Join("saves", NemirtingasRoot, "account", "game", "achievements.json"), FormatNemirtingas},
+		{filepath.Join(NemirtingasRoot, "account", "game", "other.json"), FormatJSON},
+	}
+	for _, tt := range tests {
+		if got := DetectFormat(data, tt.path); got != tt.want {
+			t.Errorf("DetectFormat(%s) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	goldberg := []byte(`[{"name": "ACH_WIN", "achieved": true}]`)
+	if got := DetectFormat(goldberg, filepath.Join(NemirtingasRoot, "account", "game", "achievements.json")); got != FormatJSON {
+		t.Errorf("a Goldberg file under the Nemirtingas folder was detected as %q", got)
+	}
+}
@@ -2,32 +2,164 @@ package parser
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Achievement struct {
-	Name     string
-	Achieved bool
+	Name       string
+	Achieved   bool
+	UnlockTime time.Time
+
+	// CurProgress and MaxProgress are set for stat-driven achievements.
+	// MaxProgress is zero when the format carries no progress.
+	CurProgress int
+	MaxProgress int
+
+	// Info holds display metadata for formats that carry their own, such as
+	// RPCS3 trophies. It is nil for formats that rely on a Steam schema.
+	Info *Info
+}
+
+// Info is display metadata read from the achievement files themselves.
+type Info struct {
+	DisplayName string
+	Description string
+	Icon        string
+	Hidden      bool
+	// Grade is the trophy grade (Bronze, Silver, ...) where there is one.
+	Grade    string
+	GameName string
+}
+
+// Result describes a parsed achievement file along with the format and
+// emulator dialect that were detected for it.
+type Result struct {
+	Format   string
+	Dialect  string
+	Encoding Encoding
+	// Source is the emulator or client that wrote the file, one of the
+	// Source constants, or "" if it couldn't be told.
+	Source       string
+	Achievements map[string]Achievement
+	// Stats holds the user stats found in the file, if the format has any.
+	Stats map[string]Stat
+	// Warnings lists the problems skipped over in lenient mode.
+	Warnings []Warning
+}
+
+// Warning describes a part of a file that could not be read and was skipped.
+type Warning struct {
+	Line    int
+	Section string
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("line %d, section %s: %s", w.Line, w.Section, w.Message)
+}
+
+// Options control how tolerant decoders are of malformed files.
+type Options struct {
+	// Lenient accepts common non-standard spellings and skips broken
+	// sections, reporting them as warnings, instead of failing the file.
+	Lenient bool
+	// SchemaNames, if set, returns the API names of the game's achievements,
+	// for formats that only store hashes of them. It is only called when
+	// such a file is decoded.
+	SchemaNames func() []string
+	// StatTypes, if set, returns the type of each of the game's stats, one
+	// of the StatType constants, by stat name, for formats that store stats
+	// as raw bits. It is only called when such a file is decoded.
+	StatTypes func() map[string]string
 }
 
 func ParseFile(reader io.Reader, filename string) (map[string]Achievement, error) {
-	ext := strings.ToLower(filepath.Ext(filename))
+	result, err := Parse(reader, filename)
+	if err != nil {
+		return nil, err
+	}
+	return result.Achievements, nil
+}
+
+// Parse reads an achievement file, detecting its format from the content and
+// using the filename's extension only as a hint.
+func Parse(reader io.Reader, filename string) (*Result, error) {
+	return ParseWithOptions(reader, filename, Options{})
+}
 
-	switch ext {
-	case ".ini":
-		return parseINI(reader)
-	case ".json":
-		return parseJSON(reader)
-	default:
-		return nil, fmt.Errorf("unsupported file format: %s", ext)
+// ParseWithOptions is Parse with control over how malformed files are handled.
+func ParseWithOptions(reader io.Reader, filename string, opts Options) (*Result, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %v", err)
+	}
+	f, data, encoding, ok := detectData(data, filename)
+	if !ok {
+		return nil, fmt.Errorf("unsupported file format: %s", filepath.Ext(filename))
+	}
+
+	result, err := f.Decode(&Input{Path: filename, Data: data, Options: opts})
+	if err != nil {
+		return nil, err
+	}
+	result.Format = f.Name
+	result.Encoding = encoding
+	result.Source = IdentifySource(filename, f.Name, result.Dialect)
+
+	if modTime, ok := fileModTime(reader, filename); ok {
+		fillUnlockTimes(result.Achievements, modTime)
+	}
+
+	return result, nil
+}
+
+// fileModTime returns the modification time of the file being parsed, from
+// the reader if it is an open file and from filename otherwise.
+func fileModTime(reader io.Reader, filename string) (time.Time, bool) {
+	if stater, ok := reader.(interface{ Stat() (os.FileInfo, error) }); ok {
+		if info, err := stater.Stat(); err == nil {
+			return info.ModTime(), true
+		}
+	}
+	if info, err := os.Stat(filename); err == nil && info.Mode().IsRegular() {
+		return info.ModTime(), true
+	}
+	return time.Time{}, false
+}
+
+// fillUnlockTimes stamps unlocked achievements whose format carries no unlock
+// time with the given fallback, usually the file's modification time.
+func fillUnlockTimes(achievements map[string]Achievement, fallback time.Time) {
+	for k, v := range achievements {
+		if v.Achieved && v.UnlockTime.IsZero() {
+			v.UnlockTime = fallback
+			achievements[k] = v
+		}
 	}
 }
 
+// parseUnixTime converts the unix timestamps emulators write into a time.
+// Zero and negative values mean "no time recorded"; values too large to be
+// seconds are treated as milliseconds.
+func parseUnixTime(value int64) time.Time {
+	if value <= 0 {
+		return time.Time{}
+	}
+	if value > 1e12 {
+		return time.UnixMilli(value)
+	}
+	return time.Unix(value, 0)
+}
+
 func shouldIncludeAchievement(name string) bool {
 	if strings.TrimSpace(name) == "" {
 		return false
@@ -40,14 +172,38 @@ func shouldIncludeAchievement(name string) bool {
 	return true
 }
 
-func parseINI(reader io.Reader) (map[string]Achievement, error) {
+// Keys, lowercased, that the various INI dialects use for an achievement's
+// state and unlock time.
+var (
+	iniAchievedKeys   = []string{"achieved", "state", "haveachieved", "unlocked", "earned"}
+	iniUnlockTimeKeys = []string{"unlocktime", "timestamp", "earned_time", "haveachievedtime"}
+)
+
+func parseINI(in *Input) (*Result, error) {
 	achievements := make(map[string]Achievement)
-	scanner := bufio.NewScanner(reader)
+	stats := make(map[string]Stat)
+	keys := make(map[string]bool)
+	var warnings []Warning
+	scanner := bufio.NewScanner(bytes.NewReader(in.Data))
 
 	var currentSection string
 	var currentAchievement Achievement
+	var sectionBroken bool
+	lineNumber := 0
+
+	// invalid reports a bad value. In lenient mode the current section is
+	// dropped and parsing carries on; otherwise the whole file fails.
+	invalid := func(message string) error {
+		if !in.Lenient {
+			return fmt.Errorf("%s in section %s (line %d)", message, currentSection, lineNumber)
+		}
+		warnings = append(warnings, Warning{Line: lineNumber, Section: currentSection, Message: message})
+		sectionBroken = true
+		return nil
+	}
 
 	for scanner.Scan() {
+		lineNumber++
 		line := strings.TrimSpace(scanner.Text())
 
 		if line == "" {
@@ -55,12 +211,13 @@ func parseINI(reader io.Reader) (map[string]Achievement, error) {
 		}
 
 		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
-			if currentSection != "" && shouldIncludeAchievement(currentSection) {
+			if currentSection != "" && !sectionBroken && !isStatsSection(currentSection) && shouldIncludeAchievement(currentSection) {
 				achievements[currentSection] = currentAchievement
 			}
 
 			currentSection = strings.Trim(line, "[]")
 			currentAchievement = Achievement{Name: currentSection}
+			sectionBroken = false
 			continue
 		}
 
@@ -70,20 +227,64 @@ func parseINI(reader io.Reader) (map[string]Achievement, error) {
 				continue
 			}
 
+			if isStatsSection(currentSection) {
+				name := strings.TrimSpace(parts[0])
+				value, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+				if err != nil {
+					if err := invalid(fmt.Sprintf("invalid stat value for '%s': %v", name, err)); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				stats[name] = Stat{Name: name, Value: value}
+				continue
+			}
+
 			key := strings.ToLower(strings.TrimSpace(parts[0]))
 			value := strings.TrimSpace(parts[1])
+			keys[key] = true
 
-			if key == "achieved" || key == "state" || key == "haveachieved" || key == "unlocked" || key == "earned" {
-				achieved, err := strconv.ParseBool(value)
+			if slices.Contains(iniAchievedKeys, key) {
+				achieved, err := parseBool(value, in.Lenient)
 				if err != nil {
-					return nil, fmt.Errorf("invalid boolean value for '%s' in section %s: %v", key, currentSection, err)
+					if err := invalid(fmt.Sprintf("invalid boolean value for '%s': %v", key, err)); err != nil {
+						return nil, err
+					}
+					continue
 				}
 				currentAchievement.Achieved = achieved
 			}
+
+			if slices.Contains(iniUnlockTimeKeys, key) {
+				// A time that can't be read only costs the time, which is
+				// filled in from the file's modification time instead.
+				unlockTime, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					message := fmt.Sprintf("ignoring invalid unlock time for '%s': %v", key, err)
+					warnings = append(warnings, Warning{Line: lineNumber, Section: currentSection, Message: message})
+					continue
+				}
+				currentAchievement.UnlockTime = parseUnixTime(unlockTime)
+			}
+
+			if key == "curprogress" || key == "maxprogress" {
+				progress, err := strconv.Atoi(value)
+				if err != nil {
+					if err := invalid(fmt.Sprintf("invalid progress value for '%s': %v", key, err)); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				if key == "curprogress" {
+					currentAchievement.CurProgress = progress
+				} else {
+					currentAchievement.MaxProgress = progress
+				}
+			}
 		}
 	}
 
-	if currentSection != "" && shouldIncludeAchievement(currentSection) {
+	if currentSection != "" && !sectionBroken && !isStatsSection(currentSection) && shouldIncludeAchievement(currentSection) {
 		achievements[currentSection] = currentAchievement
 	}
 
@@ -91,16 +292,39 @@ func parseINI(reader io.Reader) (map[string]Achievement, error) {
 		return nil, fmt.Errorf("error reading INI file: %v", err)
 	}
 
-	return achievements, nil
+	return &Result{Dialect: detectINIDialect(keys), Achievements: achievements, Stats: stats, Warnings: warnings}, nil
 }
 
-func parseJSON(reader io.Reader) (map[string]Achievement, error) {
-	var data map[string]Achievement
-	decoder := json.NewDecoder(reader)
+// isStatsSection reports whether an INI section holds stat values rather
+// than an achievement, like the [Stats] section of CODEX files.
+func isStatsSection(section string) bool {
+	return strings.EqualFold(section, "stats")
+}
+
+// parseBool parses an achieved-style flag. Lenient mode also accepts the
+// spellings emulators write besides Go's own: yes/no, on/off, hex numbers and
+// blank values.
+func parseBool(value string, lenient bool) (bool, error) {
+	if b, err := strconv.ParseBool(value); err == nil || !lenient {
+		return b, err
+	}
+
+	switch strings.ToLower(value) {
+	case "", "no", "n", "off":
+		return false, nil
+	case "yes", "y", "on":
+		return true, nil
+	}
+	if n, err := strconv.ParseInt(value, 0, 64); err == nil {
+		return n != 0, nil
+	}
+	return false, fmt.Errorf("unrecognised value %q", value)
+}
 
-	if err := decoder.Decode(&data); err != nil {
-		return nil, fmt.Errorf("error parsing JSON: %v", err)
+func parseJSON(in *Input) (*Result, error) {
+	if !json.Valid(in.Data) {
+		return nil, fmt.Errorf("error parsing JSON: invalid JSON")
 	}
 
-	return data, nil
+	return parseGoldberg(in.Data)
 }
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseUnixTime(t *testing.T) {
+	tests := []struct {
+		value int64
+		want  time.Time
+	}{
+		{1700000000, time.Unix(1700000000, 0)},
+		{1700000000123, time.UnixMilli(1700000000123)},
+		{0, time.Time{}},
+		{-1, time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := parseUnixTime(tt.value); !got.Equal(tt.want) {
+			t.Errorf("parseUnixTime(%d) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseFillsUnlockTimesFromModTime(t *testing.T) {
+	data := []byte("[ACH_WIN]\nAchieved=1\n[ACH_TIMED]\nAchieved=1\nUnlockTime=1700000000\n[ACH_LOSE]\nAchieved=0\n")
+	path := filepath.Join(t.TempDir(), "achievements.ini")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(name string, result *Result, err error, want time.Time) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got := result.Achievements["ACH_WIN"].UnlockTime; !got.Equal(want) {
+			t.Errorf("%s: untimed unlock = %v, want %v", name, got, want)
+		}
+		if got := result.Achievements["ACH_TIMED"].UnlockTime; !got.Equal(time.Unix(1700000000, 0)) {
+			t.Errorf("%s: recorded time was replaced with %v", name, got)
+		}
+		if got := result.Achievements["ACH_LOSE"].UnlockTime; !got.IsZero() {
+			t.Errorf("%s: locked achievement got time %v", name, got)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	result, err := Parse(f, path)
+	check("open file", result, err, modTime)
+
+	result, err = Parse(bytes.NewReader(data), path)
+	check("reader with a path", result, err, modTime)
+
+	result, err = Parse(bytes.NewReader(data), filepath.Join(t.TempDir(), "missing.ini"))
+	check("no file", result, err, time.Time{})
+}
@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Input is what a decoder is handed for a single file.
+type Input struct {
+	Path string
+	Data []byte
+	Options
+}
+
+// Format describes an achievement file format the parser can read.
+type Format struct {
+	Name string
+	// Patterns are filepath.Match globs, matched case-insensitively against
+	// a file's base name, that identify files in this format.
+	Patterns []string
+	// Match, if set, recognises files of this format by their full path,
+	// for formats that can't be told apart by base name alone.
+	Match func(path string) bool
+	// Sniff reports whether data looks like this format.
+	Sniff func(data []byte) bool
+	// NameRequired keeps files that aren't named like this format, by
+	// Patterns or Match, from being detected as it on content alone. It is
+	// for formats whose content is too generic to sniff reliably, or that
+	// need the name to be read at all.
+	NameRequired bool
+	// Decode reads the achievements out of a file in this format.
+	Decode func(in *Input) (*Result, error)
+	// Encode writes achievements in this format, using original (UTF-8,
+	// possibly nil) as a template. It is optional; formats without it are
+	// read-only.
+	Encode func(original []byte, dialect string, achievements map[string]Achievement) ([]byte, error)
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   []Format
+)
+
+// Register makes a format available to Parse and to file discovery. Formats
+// are sniffed in registration order. Register panics if the format is
+// incomplete or its name is already taken, so it is meant to be called from
+// an init function.
+func Register(format Format) {
+	if format.Name == "" || format.Sniff == nil || format.Decode == nil {
+		panic("parser: Register called with an incomplete format")
+	}
+
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	for _, f := range formats {
+		if f.Name == format.Name {
+			panic(fmt.Sprintf("parser: Register called twice for format %s", format.Name))
+		}
+	}
+	formats = append(formats, format)
+}
+
+// Formats returns the registered formats in registration order.
+func Formats() []Format {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	return append([]Format(nil), formats...)
+}
+
+// ignoredFolders hold files that are named like achievement files but aren't
+// any: Goldberg's steam_settings keeps the game's schema as
+// achievements.json.
+var ignoredFolders = []string{"steam_settings"}
+
+// MatchFile reports whether path is named like a file of any registered
+// format. Only such files are achievement files; their content decides which
+// of the formats decodes them.
+func MatchFile(path string) bool {
+	for _, dir := range strings.Split(filepath.Dir(path), string(filepath.Separator)) {
+		for _, ignored := range ignoredFolders {
+			if strings.EqualFold(dir, ignored) {
+				return false
+			}
+		}
+	}
+	for _, f := range Formats() {
+		if f.matchName(path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Format) matchName(path string) bool {
+	if f.Match != nil && f.Match(path) {
+		return true
+	}
+	base := strings.ToLower(filepath.Base(path))
+	for _, pattern := range f.Patterns {
+		if ok, _ := filepath.Match(strings.ToLower(pattern), base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchExt reports whether path has the extension of one of the format's
+// patterns, for files that were renamed but kept their extension.
+func (f Format) matchExt(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return false
+	}
+	for _, pattern := range f.Patterns {
+		if strings.ToLower(filepath.Ext(pattern)) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+func lookupFormat(name string) (Format, bool) {
+	for _, f := range Formats() {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Format{}, false
+}
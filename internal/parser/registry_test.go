@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRegisterRejectsBadFormats(t *testing.T) {
+	noop := func(in *Input) (*Result, error) { return nil, nil }
+	sniff := func(data []byte) bool { return false }
+
+	tests := []struct {
+		name   string
+		format Format
+	}{
+		{"no name", Format{Sniff: sniff, Decode: noop}},
+		{"no sniff", Format{Name: "test-no-sniff", Decode: noop}},
+		{"no decoder", Format{Name: "test-no-decode", Sniff: sniff}},
+		{"name taken", Format{Name: FormatINI, Sniff: sniff, Decode: noop}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected Register to panic")
+				}
+			}()
+			Register(tt.format)
+		})
+	}
+}
+
+func TestMatchFile(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{filepath.Join("CODEX", "480", "achievements.ini"), true},
+		{filepath.Join("OnlineFix", "480", "Stats", "Achievements.ini"), true},
+		{filepath.Join("GSE Saves", "480", "achievements.json"), true},
+		{filepath.Join("appcache", "stats", "UserGameStats_12345_480.bin"), true},
+		{filepath.Join("SmartSteamEmu", "480", "stats.bin"), true},
+		{filepath.Join("home", "00000001", "trophy", "NPWR00001_00", "TROPUSR.DAT"), true},
+		{filepath.Join(NemirtingasRoot, "account", "fortnite", "achievements.json"), true},
+		// Named like nothing the parser reads, whatever they contain.
+		{filepath.Join("CODEX", "480", "remote", "save.ini"), false},
+		{filepath.Join("CODEX", "480", "remote", "settings.json"), false},
+		{filepath.Join("appcache", "stats", "UserGameStatsSchema_480.bin"), false},
+		{filepath.Join("Game", "icon.png"), false},
+		// Goldberg's schema, not the player's progress.
+		{filepath.Join("Game", "steam_settings", "achievements.json"), false},
+		{filepath.Join("Game", "Steam_Settings", "stats.ini"), false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchFile(tt.path); got != tt.want {
+			t.Errorf("MatchFile(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
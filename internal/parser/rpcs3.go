@@ -0,0 +1,168 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	FormatRPCS3Trophy = "rpcs3-trophy"
+	DialectRPCS3      = "rpcs3"
+)
+
+var tropusrMagic = []byte{0x81, 0x8f, 0x54, 0xad}
+
+const (
+	tropusrHeaderSize      = 48
+	tropusrTableHeaderSize = 32
+	tropusrEntryHeaderSize = 16
+	tropusrUnlockTable     = 6
+)
+
+// ps3EpochOffset is the number of microseconds between 0001-01-01, where
+// PS3 clock ticks start, and the unix epoch.
+const ps3EpochOffset = 62135596800000000
+
+var trophyGrades = map[string]string{
+	"P": "Platinum",
+	"G": "Gold",
+	"S": "Silver",
+	"B": "Bronze",
+}
+
+func init() {
+	Register(Format{
+		Name:     FormatRPCS3Trophy,
+		Patterns: []string{"TROPUSR.DAT"},
+		Sniff:    func(data []byte) bool { return bytes.HasPrefix(data, tropusrMagic) },
+		Decode:   parseRPCS3Trophies,
+	})
+}
+
+type tropconf struct {
+	NpCommID  string `xml:"npcommid"`
+	TitleName string `xml:"title-name"`
+	Trophies  []struct {
+		ID     string `xml:"id,attr"`
+		Hidden string `xml:"hidden,attr"`
+		Type   string `xml:"ttype,attr"`
+		Name   string `xml:"name"`
+		Detail string `xml:"detail"`
+	} `xml:"trophy"`
+}
+
+// parseTropconf reads the trophy list from a TROPCONF.SFM file. Some dumps
+// carry a binary header in front of the XML, which is skipped.
+func parseTropconf(data []byte) (*tropconf, error) {
+	start := bytes.IndexByte(data, '<')
+	if start < 0 {
+		return nil, fmt.Errorf("no XML found")
+	}
+	var conf tropconf
+	if err := xml.Unmarshal(data[start:], &conf); err != nil {
+		return nil, err
+	}
+	return &conf, nil
+}
+
+type trophyUnlock struct {
+	achieved   bool
+	unlockTime time.Time
+}
+
+// parseTropusr reads the unlock table out of a TROPUSR.DAT file. All values
+// in the file are big-endian.
+func parseTropusr(data []byte) (map[uint32]trophyUnlock, error) {
+	if len(data) < tropusrHeaderSize || !bytes.HasPrefix(data, tropusrMagic) {
+		return nil, fmt.Errorf("invalid TROPUSR.DAT header")
+	}
+	be := binary.BigEndian
+	tableCount := int(be.Uint32(data[8:]))
+
+	unlocks := make(map[uint32]trophyUnlock)
+	for i := 0; i < tableCount; i++ {
+		at := tropusrHeaderSize + i*tropusrTableHeaderSize
+		if at+tropusrTableHeaderSize > len(data) {
+			return nil, fmt.Errorf("truncated TROPUSR.DAT table headers")
+		}
+		table := data[at:]
+		if be.Uint32(table) != tropusrUnlockTable {
+			continue
+		}
+		count := int(be.Uint32(table[12:]))
+		offset := int(be.Uint64(table[16:]))
+
+		for j := 0; j < count; j++ {
+			if offset < 0 || offset+40 > len(data) {
+				return nil, fmt.Errorf("truncated TROPUSR.DAT unlock table")
+			}
+			entry := data[offset:]
+			trophyID := be.Uint32(entry[16:])
+			state := be.Uint32(entry[20:])
+			ticks := be.Uint64(entry[32:])
+
+			u := trophyUnlock{achieved: state != 0}
+			if u.achieved && ticks > ps3EpochOffset {
+				u.unlockTime = time.UnixMicro(int64(ticks - ps3EpochOffset))
+			}
+			unlocks[trophyID] = u
+			offset += tropusrEntryHeaderSize + int(be.Uint32(entry[4:]))
+		}
+	}
+	return unlocks, nil
+}
+
+// parseRPCS3Trophies decodes an RPCS3 trophy folder. TROPUSR.DAT holds the
+// unlock state by trophy number, and TROPCONF.SFM next to it names the
+// trophies; their icons are the TROPnnn.PNG files in the same folder.
+func parseRPCS3Trophies(in *Input) (*Result, error) {
+	unlocks, err := parseTropusr(in.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(in.Path)
+	confData, err := os.ReadFile(filepath.Join(dir, "TROPCONF.SFM"))
+	if err != nil {
+		return nil, fmt.Errorf("error reading TROPCONF.SFM: %v", err)
+	}
+	conf, err := parseTropconf(confData)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing TROPCONF.SFM: %v", err)
+	}
+
+	achievements := make(map[string]Achievement)
+	for _, t := range conf.Trophies {
+		var number uint32
+		if _, err := fmt.Sscanf(t.ID, "%d", &number); err != nil {
+			continue
+		}
+		u := unlocks[number]
+
+		info := &Info{
+			DisplayName: t.Name,
+			Description: t.Detail,
+			Hidden:      t.Hidden == "yes",
+			Grade:       trophyGrades[t.Type],
+			GameName:    conf.TitleName,
+		}
+		icon := filepath.Join(dir, fmt.Sprintf("TROP%03d.PNG", number))
+		if _, err := os.Stat(icon); err == nil {
+			info.Icon = icon
+		}
+
+		achievements[t.ID] = Achievement{
+			Name:       t.ID,
+			Achieved:   u.achieved,
+			UnlockTime: u.unlockTime,
+			Info:       info,
+		}
+	}
+
+	return &Result{Dialect: DialectRPCS3, Achievements: achievements}, nil
+}
@@ -0,0 +1,148 @@
+package parser
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type tropusrEntry struct {
+	id     uint32
+	state  uint32
+	ticks  uint64
+	length uint32
+}
+
+// buildTropusr lays out a TROPUSR.DAT with a single unlock table.
+func buildTropusr(entries []tropusrEntry) []byte {
+	be := binary.BigEndian
+	data := make([]byte, tropusrHeaderSize+tropusrTableHeaderSize)
+	copy(data, tropusrMagic)
+	be.PutUint32(data[8:], 1)
+
+	table := data[tropusrHeaderSize:]
+	be.PutUint32(table, tropusrUnlockTable)
+	be.PutUint32(table[12:], uint32(len(entries)))
+	be.PutUint64(table[16:], uint64(len(data)))
+
+	for _, e := range entries {
+		if e.length == 0 {
+			e.length = 32
+		}
+		entry := make([]byte, tropusrEntryHeaderSize+int(e.length))
+		be.PutUint32(entry, tropusrUnlockTable)
+		be.PutUint32(entry[4:], e.length)
+		be.PutUint32(entry[16:], e.id)
+		be.PutUint32(entry[20:], e.state)
+		be.PutUint64(entry[32:], e.ticks)
+		data = append(data, entry...)
+	}
+	return data
+}
+
+func ps3Ticks(t time.Time) uint64 {
+	return uint64(t.UnixMicro()) + ps3EpochOffset
+}
+
+const tropconfFixture = "\x00\x00\x00\x01binary header" + `<?xml version="1.0" encoding="UTF-8"?>
+<trophyconf version="1.0">
+  <npcommid>NPWR00001_00</npcommid>
+  <title-name>Test Game</title-name>
+  <trophy id="000" hidden="no" ttype="P" pid="000"><name>Platinum</name><detail>Get them all</detail></trophy>
+  <trophy id="001" hidden="yes" ttype="B" pid="000"><name>First</name><detail>Do something</detail></trophy>
+  <trophy id="002" hidden="no" ttype="G" pid="000"><name>Second</name><detail>Do more</detail></trophy>
+</trophyconf>`
+
+func writeTrophyFolder(t *testing.T, tropusr []byte, tropconf string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "home", "00000001", "trophy", "NPWR00001_00")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if tropconf != "" {
+		if err := os.WriteFile(filepath.Join(dir, "TROPCONF.SFM"), []byte(tropconf), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "TROP001.PNG"), []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "TROPUSR.DAT")
+	if err := os.WriteFile(path, tropusr, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseRPCS3Trophies(t *testing.T) {
+	unlocked := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	data := buildTropusr([]tropusrEntry{
+		{id: 0, state: 0},
+		{id: 1, state: 1, ticks: ps3Ticks(unlocked), length: 48},
+		{id: 2, state: 1},
+	})
+	path := writeTrophyFolder(t, data, tropconfFixture)
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	result, err := Parse(f, path)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if result.Format != FormatRPCS3Trophy || result.Source != SourceRPCS3 {
+		t.Errorf("format %s, source %s", result.Format, result.Source)
+	}
+
+	first := result.Achievements["001"]
+	if !first.Achieved || !first.UnlockTime.Equal(unlocked) {
+		t.Errorf("trophy 001 = %+v", first)
+	}
+	if info := first.Info; info == nil || info.DisplayName != "First" || info.Grade != "Bronze" || !info.Hidden ||
+		info.GameName != "Test Game" || filepath.Base(info.Icon) != "TROP001.PNG" {
+		t.Errorf("trophy 001 info = %+v", first.Info)
+	}
+	if result.Achievements["000"].Achieved {
+		t.Error("trophy 000 should be locked")
+	}
+	// Without a time in the file, the file's modification time stands in.
+	if second := result.Achievements["002"]; !second.Achieved || second.UnlockTime.IsZero() || second.Info.Icon != "" {
+		t.Errorf("trophy 002 = %+v, info %+v", second, second.Info)
+	}
+}
+
+func TestParseRPCS3TrophiesMalformed(t *testing.T) {
+	valid := buildTropusr([]tropusrEntry{{id: 0, state: 1}, {id: 1, state: 0}})
+
+	badMagic := append([]byte(nil), valid...)
+	badMagic[0] = 0
+	tooManyTables := append([]byte(nil), valid...)
+	binary.BigEndian.PutUint32(tooManyTables[8:], 50)
+
+	tests := []struct {
+		name     string
+		tropusr  []byte
+		tropconf string
+	}{
+		{"short header", valid[:20], tropconfFixture},
+		{"bad magic", badMagic, tropconfFixture},
+		{"truncated table headers", tooManyTables, tropconfFixture},
+		{"truncated unlock table", valid[:len(valid)-20], tropconfFixture},
+		{"missing TROPCONF.SFM", valid, ""},
+		{"TROPCONF.SFM without XML", valid, "\x00\x01\x02"},
+		{"broken TROPCONF.SFM", valid, "<trophyconf><trophy id=\"000\">"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTrophyFolder(t, tt.tropusr, tt.tropconf)
+			if _, err := parseRPCS3Trophies(&Input{Path: path, Data: tt.tropusr}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
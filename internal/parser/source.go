@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Sources are the emulators and clients an achievement file can come from,
+// spelled the way they are shown to the user.
+const (
+	SourceCodex       = "CODEX"
+	SourceRune        = "RUNE"
+	SourceOnlineFix   = "OnlineFix"
+	SourceEmpress     = "EMPRESS"
+	SourceGoldberg    = "Goldberg"
+	SourceSSE         = "SmartSteamEmu"
+	SourceCreamAPI    = "CreamAPI"
+	SourceSkidrow     = "SKIDROW"
+	SourceAli213      = "ALI213"
+	SourceSteam       = "Steam"
+	SourceRPCS3       = "RPCS3"
+	SourceNemirtingas = "Nemirtingas"
+	SourceLumaPlay    = "LumaPlay"
+)
+
+// sourceFolders maps the folder names emulators save under, lowercased, to
+// the emulator. Folders are the most reliable sign, since several emulators
+// share a file format.
+var sourceFolders = map[string]string{
+	"codex":                          SourceCodex,
+	"rune":                           SourceRune,
+	"onlinefix":                      SourceOnlineFix,
+	"empress":                        SourceEmpress,
+	"goldberg steamemu saves":        SourceGoldberg,
+	"gse saves":                      SourceGoldberg,
+	"smartsteamemu":                  SourceSSE,
+	"creamapi":                       SourceCreamAPI,
+	"skidrow":                        SourceSkidrow,
+	"ali213":                         SourceAli213,
+	strings.ToLower(NemirtingasRoot): SourceNemirtingas,
+	strings.ToLower(LumaPlayRoot):    SourceLumaPlay,
+}
+
+var formatSources = map[string]string{
+	FormatSteamUserGameStats: SourceSteam,
+	FormatRPCS3Trophy:        SourceRPCS3,
+	FormatSSEStats:           SourceSSE,
+	FormatNemirtingas:        SourceNemirtingas,
+	FormatLumaPlay:           SourceLumaPlay,
+	FormatGoldbergStat:       SourceGoldberg,
+}
+
+var dialectSources = map[string]string{
+	DialectCodex:          SourceCodex,
+	DialectOnlineFix:      SourceOnlineFix,
+	DialectAli213:         SourceAli213,
+	DialectGoldberg:       SourceGoldberg,
+	DialectGoldbergLegacy: SourceGoldberg,
+}
+
+// IdentifySource works out which emulator wrote an achievement file, from
+// the folders it is saved under and, failing that, from the format and
+// dialect it was read as. Either may be empty when the file couldn't be
+// parsed. It returns "" when the emulator can't be told.
+func IdentifySource(path string, format string, dialect string) string {
+	dirs := strings.FieldsFunc(filepath.Dir(path), isPathSeparator)
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if source, ok := sourceFolders[strings.ToLower(dirs[i])]; ok {
+			return source
+		}
+	}
+	if source, ok := formatSources[format]; ok {
+		return source
+	}
+	return dialectSources[dialect]
+}
@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+)
+
+const (
+	FormatSSEStats = "sse-stats"
+	DialectSSE     = "sse"
+)
+
+const sseRecordSize = 24
+
+func init() {
+	Register(Format{
+		Name:     FormatSSEStats,
+		Patterns: []string{"stats.bin"},
+		Sniff:    sniffSSEStats,
+		Decode:   parseSSEStats,
+	})
+}
+
+// sniffSSEStats checks that the record count in the header accounts for the
+// whole file, which is all the structure stats.bin has.
+func sniffSSEStats(data []byte) bool {
+	if len(data) < 4+sseRecordSize {
+		return false
+	}
+	count := binary.LittleEndian.Uint32(data)
+	return uint64(len(data)) == 4+uint64(count)*sseRecordSize
+}
+
+// parseSSEStats decodes a SmartSteamEmu stats.bin file: a little-endian
+// record count followed by fixed-size records keyed by the CRC32 of the
+// achievement's API name. The names come from Options.SchemaNames; records
+// that match no name are stats or achievements the schema doesn't know,
+// and are skipped.
+func parseSSEStats(in *Input) (*Result, error) {
+	if !sniffSSEStats(in.Data) {
+		return nil, fmt.Errorf("invalid SmartSteamEmu stats.bin")
+	}
+	var names []string
+	if in.SchemaNames != nil {
+		names = in.SchemaNames()
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no achievement schema to resolve SmartSteamEmu stats.bin")
+	}
+
+	byHash := make(map[uint32]string, len(names))
+	for _, name := range names {
+		byHash[crc32.ChecksumIEEE([]byte(name))] = name
+	}
+
+	le := binary.LittleEndian
+	achievements := make(map[string]Achievement)
+	for record := in.Data[4:]; len(record) >= sseRecordSize; record = record[sseRecordSize:] {
+		name, ok := byHash[le.Uint32(record)]
+		if !ok || !shouldIncludeAchievement(name) {
+			continue
+		}
+		a := Achievement{Name: name, Achieved: record[20] != 0}
+		if a.Achieved {
+			a.UnlockTime = parseUnixTime(int64(le.Uint32(record[8:])))
+		}
+		achievements[name] = a
+	}
+
+	return &Result{Dialect: DialectSSE, Achievements: achievements}, nil
+}
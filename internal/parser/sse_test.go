@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+	"time"
+)
+
+type sseRecord struct {
+	name     string
+	achieved bool
+	time     uint32
+}
+
+func buildSSEStats(records []sseRecord) []byte {
+	le := binary.LittleEndian
+	data := le.AppendUint32(nil, uint32(len(records)))
+	for _, r := range records {
+		record := make([]byte, sseRecordSize)
+		le.PutUint32(record, crc32.ChecksumIEEE([]byte(r.name)))
+		le.PutUint32(record[8:], r.time)
+		if r.achieved {
+			record[20] = 1
+		}
+		data = append(data, record...)
+	}
+	return data
+}
+
+func TestParseSSEStats(t *testing.T) {
+	data := buildSSEStats([]sseRecord{
+		{"ACH_WIN", true, 1700000000},
+		{"ACH_LOSE", false, 1700000000},
+		{"stat_kills", false, 0},
+	})
+	names := func() []string { return []string{"ACH_WIN", "ACH_LOSE", "ACH_TRAVEL"} }
+
+	result, err := ParseWithOptions(bytes.NewReader(data), "stats.bin", Options{SchemaNames: names})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if result.Format != FormatSSEStats {
+		t.Errorf("format = %s", result.Format)
+	}
+	// The stat has no name in the schema, and ACH_TRAVEL has no record.
+	assertAchievements(t, result.Achievements, map[string]Achievement{
+		"ACH_WIN":  {Name: "ACH_WIN", Achieved: true, UnlockTime: time.Unix(1700000000, 0)},
+		"ACH_LOSE": {Name: "ACH_LOSE"},
+	})
+}
+
+func TestParseSSEStatsMalformed(t *testing.T) {
+	valid := buildSSEStats([]sseRecord{{"ACH_WIN", true, 1700000000}})
+	names := func() []string { return []string{"ACH_WIN"} }
+	noNames := func() []string { return nil }
+
+	overcounted := append([]byte(nil), valid...)
+	binary.LittleEndian.PutUint32(overcounted, 2)
+
+	tests := []struct {
+		name  string
+		data  []byte
+		names func() []string
+	}{
+		{"truncated record", valid[:len(valid)-1], names},
+		{"count beyond the data", overcounted, names},
+		{"header only", valid[:4], names},
+		{"no schema names", valid, noNames},
+		{"no schema resolver", valid, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := &Input{Path: "stats.bin", Data: tt.data, Options: Options{SchemaNames: tt.names}}
+			if _, err := parseSSEStats(in); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestSniffSSEStats(t *testing.T) {
+	if !sniffSSEStats(buildSSEStats([]sseRecord{{"A", false, 0}, {"B", false, 0}})) {
+		t.Error("a well-formed file should be recognised")
+	}
+	if sniffSSEStats([]byte("[ACH_WIN]\nAchieved=1\nUnlockTime=17000000\n")) {
+		t.Error("an INI file should not be recognised")
+	}
+}
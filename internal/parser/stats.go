@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// Stat is a user stat such as a kill counter. Emulators store stats as
+// integers or floats; Value holds either.
+type Stat struct {
+	Name  string
+	Value float64
+}
+
+// Stat types, as Options.StatTypes gives them.
+const (
+	StatTypeInt     = "int"
+	StatTypeFloat   = "float"
+	StatTypeAvgRate = "avgrate"
+)
+
+func init() {
+	Register(Format{
+		Name:  FormatGoldbergStat,
+		Match: isGoldbergStatFile,
+		Sniff: func(data []byte) bool { return len(data) == 4 },
+		// Any four bytes pass the sniff, so only files in a stats
+		// directory are taken for stats.
+		NameRequired: true,
+		Decode:       parseGoldbergStat,
+	})
+}
+
+// isGoldbergStatFile reports whether path is one of the files Goldberg keeps
+// in a save's stats directory, one per stat, named after the stat.
+func isGoldbergStatFile(path string) bool {
+	return strings.EqualFold(filepath.Base(filepath.Dir(path)), "stats") && filepath.Ext(path) == ""
+}
+
+// parseGoldbergStat reads a Goldberg stat file, which holds the stat's value
+// as 32 little-endian bits: an integer or a float, depending on the stat's
+// type in the game's schema. Stats the schema doesn't list are read as
+// integers, the most common type.
+func parseGoldbergStat(in *Input) (*Result, error) {
+	if len(in.Data) != 4 {
+		return nil, fmt.Errorf("invalid Goldberg stat file: expected 4 bytes, got %d", len(in.Data))
+	}
+
+	name := filepath.Base(in.Path)
+	var statType string
+	if in.StatTypes != nil {
+		statType = in.StatTypes()[name]
+	}
+
+	bits := binary.LittleEndian.Uint32(in.Data)
+	value := float64(int32(bits))
+	if statType == StatTypeFloat || statType == StatTypeAvgRate {
+		value = float64(math.Float32frombits(bits))
+	}
+	return &Result{
+		Dialect:      DialectGoldberg,
+		Achievements: make(map[string]Achievement),
+		Stats:        map[string]Stat{name: {Name: name, Value: value}},
+	}, nil
+}
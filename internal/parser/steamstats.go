@@ -0,0 +1,167 @@
+package parser
+
+import (
+	"Achievement-Thing/pkg/keyvalues"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+const (
+	FormatSteamUserGameStats = "steam-usergamestats"
+	DialectSteam             = "steam"
+)
+
+// Stat types used in Steam's UserGameStatsSchema files.
+const (
+	steamStatInt               = 1
+	steamStatFloat             = 2
+	steamStatAvgRate           = 3
+	steamStatAchievements      = 4
+	steamStatGroupAchievements = 5
+)
+
+var userGameStatsName = regexp.MustCompile(`(?i)^UserGameStats_(\d+)_(\d+)\.bin$`)
+
+func init() {
+	Register(Format{
+		Name:     FormatSteamUserGameStats,
+		Patterns: []string{"UserGameStats_*_*.bin"},
+		Sniff:    sniffBinaryKeyValues,
+		// The schema that gives the file meaning is found by its name, and
+		// the schema files themselves are binary KeyValues too.
+		NameRequired: true,
+		Decode:       parseSteamUserGameStats,
+	})
+}
+
+func sniffBinaryKeyValues(data []byte) bool {
+	if len(data) < 2 || data[0] != byte(keyvalues.TypeNone) {
+		return false
+	}
+	_, err := keyvalues.ParseBinary(data)
+	return err == nil
+}
+
+// SplitUserGameStatsName extracts the Steam account ID and app ID from the
+// name of a UserGameStats_<account>_<appid>.bin file.
+func SplitUserGameStatsName(path string) (account string, appId string, ok bool) {
+	m := userGameStatsName.FindStringSubmatch(filepath.Base(path))
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+// SteamSchemaPath returns the UserGameStatsSchema file that describes the
+// UserGameStats file at path, which Steam keeps in the same folder.
+func SteamSchemaPath(path string) (string, error) {
+	_, appId, ok := SplitUserGameStatsName(path)
+	if !ok {
+		return "", fmt.Errorf("not a UserGameStats file: %s", filepath.Base(path))
+	}
+	return filepath.Join(filepath.Dir(path), "UserGameStatsSchema_"+appId+".bin"), nil
+}
+
+// steamStatSchema is what the schema says about one stat: either a numeric
+// stat, or a block of achievements packed as bits of the stat's value.
+type steamStatSchema struct {
+	name string
+	kind int64
+	bits map[int]string
+}
+
+func parseSteamSchema(data []byte) (map[string]steamStatSchema, error) {
+	root, err := keyvalues.ParseBinary(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(root.Children) == 0 {
+		return nil, fmt.Errorf("empty schema")
+	}
+
+	schema := make(map[string]steamStatSchema)
+	for _, stat := range root.Children[0].Child("stats").Children {
+		kind, _ := stat.Child("type").Int()
+		s := steamStatSchema{name: stat.Child("name").String(), kind: kind}
+		if kind == steamStatAchievements || kind == steamStatGroupAchievements {
+			s.bits = make(map[int]string)
+			for _, bit := range stat.Child("bits").Children {
+				index, ok := bit.Child("bit").Int()
+				if !ok {
+					n, err := strconv.Atoi(bit.Name)
+					if err != nil {
+						continue
+					}
+					index = int64(n)
+				}
+				s.bits[int(index)] = bit.Child("name").String()
+			}
+		}
+		schema[stat.Name] = s
+	}
+	return schema, nil
+}
+
+// parseSteamUserGameStats decodes a Steam client UserGameStats file. The
+// file only holds raw stat values; which bits of which stat are which
+// achievement comes from the UserGameStatsSchema file next to it.
+func parseSteamUserGameStats(in *Input) (*Result, error) {
+	schemaPath, err := SteamSchemaPath(in.Path)
+	if err != nil {
+		return nil, err
+	}
+	schemaData, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading Steam stats schema: %v", err)
+	}
+	schema, err := parseSteamSchema(schemaData)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing Steam stats schema: %v", err)
+	}
+
+	root, err := keyvalues.ParseBinary(in.Data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing Steam user stats: %v", err)
+	}
+	if len(root.Children) == 0 {
+		return nil, fmt.Errorf("error parsing Steam user stats: empty file")
+	}
+
+	achievements := make(map[string]Achievement)
+	stats := make(map[string]Stat)
+	userStats := root.Children[0]
+	for id, s := range schema {
+		node := userStats.Child(id)
+		raw, _ := node.Child("data").Int()
+		value := uint32(raw)
+
+		switch s.kind {
+		case steamStatAchievements, steamStatGroupAchievements:
+			times := node.Child("AchievementTimes")
+			for bit, name := range s.bits {
+				if !shouldIncludeAchievement(name) {
+					continue
+				}
+				a := Achievement{Name: name, Achieved: value&(1<<uint(bit)) != 0}
+				if t, ok := times.Child(strconv.Itoa(bit)).Int(); ok && a.Achieved {
+					a.UnlockTime = parseUnixTime(t)
+				}
+				achievements[name] = a
+			}
+		case steamStatFloat, steamStatAvgRate:
+			if s.name != "" && node != nil {
+				stats[s.name] = Stat{Name: s.name, Value: float64(math.Float32frombits(value))}
+			}
+		default:
+			if s.name != "" && node != nil {
+				stats[s.name] = Stat{Name: s.name, Value: float64(int32(value))}
+			}
+		}
+	}
+
+	return &Result{Dialect: DialectSteam, Achievements: achievements, Stats: stats}, nil
+}
@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// kvInt and kvString encode binary KeyValues entries; kvSection wraps
+// entries in a section.
+func kvInt(name string, v int32) []byte {
+	out := append([]byte{0x02}, name...)
+	return binary.LittleEndian.AppendUint32(append(out, 0), uint32(v))
+}
+
+func kvString(name string, v string) []byte {
+	out := append([]byte{0x01}, name...)
+	return append(append(append(out, 0), v...), 0)
+}
+
+func kvSection(name string, children ...[]byte) []byte {
+	out := append([]byte{0x00}, name...)
+	out = append(out, 0)
+	for _, c := range children {
+		out = append(out, c...)
+	}
+	return append(out, 0x08)
+}
+
+func kvDocument(sections ...[]byte) []byte {
+	var out []byte
+	for _, s := range sections {
+		out = append(out, s...)
+	}
+	return append(out, 0x08)
+}
+
+func writeSteamStatsFixture(t *testing.T, userStats []byte) string {
+	t.Helper()
+	dir := t.TempDir()
+	schema := kvDocument(kvSection("480", kvSection("stats",
+		kvSection("1",
+			kvInt("type", steamStatAchievements),
+			kvSection("bits",
+				kvSection("0", kvInt("bit", 0), kvString("name", "ACH_WIN")),
+				kvSection("1", kvInt("bit", 1), kvString("name", "ACH_LOSE")),
+				kvSection("2", kvString("name", "ACH_TRAVEL")),
+			),
+		),
+		kvSection("2", kvInt("type", steamStatInt), kvString("name", "kills")),
+		kvSection("3", kvInt("type", steamStatFloat), kvString("name", "accuracy")),
+	)))
+	if err := os.WriteFile(filepath.Join(dir, "UserGameStatsSchema_480.bin"), schema, 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "UserGameStats_12345_480.bin")
+	if err := os.WriteFile(path, userStats, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseSteamUserGameStats(t *testing.T) {
+	userStats := kvDocument(kvSection("cache",
+		kvSection("1", kvInt("data", 0b101), kvSection("AchievementTimes", kvInt("0", 1700000000), kvInt("2", 1700000100))),
+		kvSection("2", kvInt("data", -4)),
+		kvSection("3", kvInt("data", int32(math.Float32bits(0.25)))),
+	))
+	path := writeSteamStatsFixture(t, userStats)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := parseSteamUserGameStats(&Input{Path: path, Data: data})
+	if err != nil {
+		t.Fatalf("parseSteamUserGameStats: %v", err)
+	}
+
+	assertAchievements(t, result.Achievements, map[string]Achievement{
+		"ACH_WIN":    {Name: "ACH_WIN", Achieved: true, UnlockTime: time.Unix(1700000000, 0)},
+		"ACH_LOSE":   {Name: "ACH_LOSE"},
+		"ACH_TRAVEL": {Name: "ACH_TRAVEL", Achieved: true, UnlockTime: time.Unix(1700000100, 0)},
+	})
+	if result.Stats["kills"].Value != -4 || result.Stats["accuracy"].Value != 0.25 {
+		t.Errorf("stats = %+v", result.Stats)
+	}
+	if account, appId, ok := SplitUserGameStatsName(path); !ok || account != "12345" || appId != "480" {
+		t.Errorf("SplitUserGameStatsName = %s, %s, %v", account, appId, ok)
+	}
+}
+
+func TestParseSteamUserGameStatsMalformed(t *testing.T) {
+	valid := kvDocument(kvSection("cache", kvSection("1", kvInt("data", 1))))
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"truncated", valid[:len(valid)-4]},
+		{"empty", []byte{0x08}},
+		{"unknown type", []byte{0x00, 'c', 0, 0x09, 'x', 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeSteamStatsFixture(t, tt.data)
+			if _, err := parseSteamUserGameStats(&Input{Path: path, Data: tt.data}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	t.Run("missing schema", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "UserGameStats_12345_480.bin")
+		if _, err := parseSteamUserGameStats(&Input{Path: path, Data: valid}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
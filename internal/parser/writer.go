@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"Achievement-Thing/pkg/atomicfile"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrEncodeUnsupported is returned when a format can be read but not written.
+var ErrEncodeUnsupported = errors.New("format does not support writing")
+
+// Encode serialises achievements in the given format and dialect. original is
+// the file being replaced, already decoded to UTF-8, or nil to write a new
+// file; keys, comments and ordering from it that the parser doesn't model are
+// kept. Achievements in original that are missing from achievements are left
+// as they are.
+func Encode(format, dialect string, original []byte, achievements map[string]Achievement) ([]byte, error) {
+	f, ok := lookupFormat(format)
+	if !ok {
+		return nil, fmt.Errorf("unsupported file format: %s", format)
+	}
+	if f.Encode == nil {
+		return nil, fmt.Errorf("%s: %w", format, ErrEncodeUnsupported)
+	}
+	return f.Encode(original, dialect, achievements)
+}
+
+// WriteFile updates the achievement file at path with achievements, keeping
+// its format, dialect and text encoding. If the file doesn't exist yet the
+// format is picked from its name. The file is replaced atomically.
+func WriteFile(path string, achievements map[string]Achievement) error {
+	format, dialect, encoding := "", DialectGeneric, EncodingUTF8
+	var original []byte
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		result, err := Parse(bytes.NewReader(data), path)
+		if err != nil {
+			return err
+		}
+		original, _ = decodeText(data)
+		format, dialect, encoding = result.Format, result.Dialect, result.Encoding
+	case os.IsNotExist(err):
+		for _, f := range Formats() {
+			if f.matchName(path) || f.matchExt(path) {
+				format = f.Name
+				break
+			}
+		}
+		if format == "" {
+			return fmt.Errorf("unsupported file format: %s", filepath.Ext(path))
+		}
+	default:
+		return fmt.Errorf("error reading file: %v", err)
+	}
+
+	encoded, err := Encode(format, dialect, original, achievements)
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFile(path, encodeText(encoded, encoding)); err != nil {
+		return fmt.Errorf("error writing file: %v", err)
+	}
+	return nil
+}
+
+// SetAchieved locks or unlocks a single achievement in the file at path,
+// leaving everything else in the file untouched.
+func SetAchieved(path, name string, achieved bool, unlockTime time.Time) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	result, err := Parse(f, path)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	achievement, ok := result.Achievements[name]
+	if !ok {
+		achievement = Achievement{Name: name}
+	}
+	achievement.Achieved = achieved
+	achievement.UnlockTime = unlockTime
+	if !achieved {
+		achievement.UnlockTime = time.Time{}
+	}
+
+	return WriteFile(path, map[string]Achievement{name: achievement})
+}
+
+// encodeText is the inverse of decodeText, so rewritten files keep the
+// encoding they were read in.
+func encodeText(text []byte, encoding Encoding) []byte {
+	switch encoding {
+	case EncodingUTF8BOM:
+		return append(append([]byte(nil), bomUTF8...), text...)
+	case EncodingUTF16LE, EncodingUTF16LEBOM, EncodingUTF16BE, EncodingUTF16BEBOM:
+		var order binary.AppendByteOrder = binary.LittleEndian
+		var out []byte
+		switch encoding {
+		case EncodingUTF16LEBOM:
+			out = append(out, bomUTF16LE...)
+		case EncodingUTF16BEBOM:
+			out = append(out, bomUTF16BE...)
+		}
+		if encoding == EncodingUTF16BE || encoding == EncodingUTF16BEBOM {
+			order = binary.BigEndian
+		}
+		for len(text) > 0 {
+			r, size := utf8.DecodeRune(text)
+			text = text[size:]
+			for _, u := range utf16.Encode([]rune{r}) {
+				out = order.AppendUint16(out, u)
+			}
+		}
+		return out
+	}
+	return text
+}
+
+// sortedNames returns the keys of achievements in a stable order, used when
+// new entries have to be appended to a file.
+func sortedNames(achievements map[string]Achievement) []string {
+	names := make([]string, 0, len(achievements))
+	for name := range achievements {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func utf16LE(s string) []byte {
+	var out []byte
+	for _, r := range s {
+		out = append(out, byte(r), byte(r>>8))
+	}
+	return out
+}
+
+const codexFixture = "[SteamAchievements]\r\n00000=ACH_WIN\r\n00001=ACH_LOSE\r\nCount=2\r\n\r\n" +
+	"[ACH_WIN]\r\nAchieved=1\r\nCurProgress=0\r\nMaxProgress=0\r\nUnlockTime=1700000000\r\n\r\n" +
+	"[ACH_LOSE]\r\nAchieved=0\r\nCurProgress=0\r\nMaxProgress=0\r\nUnlockTime=0\r\n"
+
+const goldbergFixture = `{
+    "ACH_WIN": {
+        "earned": true,
+        "earned_time": 1700000000
+    },
+    "ACH_LOSE": {
+        "earned": false,
+        "earned_time": 0
+    }
+}
+`
+
+func TestWriteFileRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		data     []byte
+	}{
+		{"CODEX INI", "achievements.ini", []byte(codexFixture)},
+		{"OnlineFix INI", "achievements.ini", []byte("[ACH_WIN]\nachieved=true\ntimestamp=1700000000\n")},
+		{"UTF-8 BOM INI", "achievements.ini", append([]byte("\xef\xbb\xbf"), codexFixture...)},
+		{"UTF-16LE INI with BOM", "achievements.ini", append([]byte("\xff\xfe"), utf16LE(codexFixture)...)},
+		{"UTF-16LE INI without BOM", "achievements.ini", utf16LE(codexFixture)},
+		{"Goldberg JSON", "achievements.json", []byte(goldbergFixture)},
+		{"Goldberg legacy JSON", "achievements.json", []byte("[{\"name\":\"ACH_WIN\",\"achieved\":true,\"unlock_time\":1700000000}]")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.filename)
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			result, err := Parse(bytes.NewReader(tt.data), path)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			if err := WriteFile(path, result.Achievements); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.data) {
+				t.Errorf("round trip changed the file:\n got %q\nwant %q", got, tt.data)
+			}
+		})
+	}
+}
+
+func TestSetAchieved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "achievements.ini")
+	if err := os.WriteFile(path, []byte(codexFixture), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetAchieved(path, "ACH_LOSE", true, time.Unix(1700000500, 0)); err != nil {
+		t.Fatalf("SetAchieved: %v", err)
+	}
+	if err := SetAchieved(path, "ACH_NEW", true, time.Unix(1700000600, 0)); err != nil {
+		t.Fatalf("SetAchieved: %v", err)
+	}
+
+	want := "[SteamAchievements]\r\n00000=ACH_WIN\r\n00001=ACH_LOSE\r\nCount=3\r\n00002=ACH_NEW\r\n\r\n" +
+		"[ACH_WIN]\r\nAchieved=1\r\nCurProgress=0\r\nMaxProgress=0\r\nUnlockTime=1700000000\r\n\r\n" +
+		"[ACH_LOSE]\r\nAchieved=1\r\nCurProgress=0\r\nMaxProgress=0\r\nUnlockTime=1700000500\r\n\r\n" +
+		"[ACH_NEW]\r\nAchieved=1\r\nUnlockTime=1700000600\r\n"
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestEncodeUnsupportedFormats(t *testing.T) {
+	achievements := map[string]Achievement{"ACH_WIN": {Name: "ACH_WIN", Achieved: true}}
+	for _, format := range []string{FormatSSEStats, FormatRPCS3Trophy, FormatSteamUserGameStats, FormatGoldbergStat} {
+		t.Run(format, func(t *testing.T) {
+			if _, err := Encode(format, "", nil, achievements); !errors.Is(err, ErrEncodeUnsupported) {
+				t.Errorf("Encode error = %v, want ErrEncodeUnsupported", err)
+			}
+		})
+	}
+
+	if _, err := Encode("no-such-format", "", nil, achievements); err == nil || errors.Is(err, ErrEncodeUnsupported) {
+		t.Errorf("Encode of an unknown format: error = %v", err)
+	}
+}
+
+func TestWriteFileUnsupportedFormat(t *testing.T) {
+	data := buildTropusr([]tropusrEntry{{id: 0, state: 0}})
+	path := writeTrophyFolder(t, data, tropconfFixture)
+
+	err := SetAchieved(path, "000", true, time.Now())
+	if !errors.Is(err, ErrEncodeUnsupported) {
+		t.Errorf("SetAchieved error = %v, want ErrEncodeUnsupported", err)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, data) {
+		t.Error("the trophy file was changed")
+	}
+}
@@ -10,6 +10,66 @@ import (
 type Settings struct {
 	ApiKey  string   `json:"apiKey"`
 	Folders []string `json:"folders"`
+	// SteamPath is the Steam install used for keyless achievement metadata.
+	SteamPath string `json:"steamPath"`
+	// Language is the Steam API name of the language achievements are shown
+	// in, such as "german" or "brazilian". Untranslated strings fall back to
+	// English.
+	Language string `json:"language"`
+	// GameFolders are scanned for games that ship a Goldberg steam_settings
+	// folder with their own achievement schema and icons.
+	GameFolders []string `json:"gameFolders"`
+	// Rpcs3Path is the RPCS3 install folder; its trophy folders under
+	// dev_hdd0/home are watched alongside Folders.
+	Rpcs3Path string `json:"rpcs3Path"`
+	// ProgressThresholds are the completion percentages at which a progress
+	// notification is sent for stat-driven achievements.
+	ProgressThresholds []int `json:"progressThresholds"`
+	// LenientParsing skips malformed sections of achievement files instead
+	// of rejecting the whole file.
+	LenientParsing bool `json:"lenientParsing"`
+	// NotifyProfiles limits notifications to these emulator profiles
+	// (SteamIDs or account names). Empty means every profile notifies.
+	// Unlocks read from log sources belong to no profile and always notify.
+	NotifyProfiles []string `json:"notifyProfiles"`
+	// AppIdOverrides maps achievement files or folders to the app ID their
+	// achievements belong to, for layouts the app ID can't be read from.
+	AppIdOverrides map[string]string `json:"appIdOverrides"`
+	// RarityTiers style unlock notifications by how many players have the
+	// achievement. An unlock gets the first tier, in order of MaxPercent,
+	// whose MaxPercent its unlock percentage doesn't exceed.
+	RarityTiers []RarityTier `json:"rarityTiers"`
+	// LogSources are log files that announce unlocks, for tools that don't
+	// keep an achievement file.
+	LogSources []LogSource `json:"logSources"`
+}
+
+// RarityTier is how unlocks of achievements held by at most MaxPercent
+// percent of players are notified: with Sound, a toast audio source such as
+// "ms-winsoundevent:Notification.IM", the tier's Name as an attribution
+// line, and a frame of FrameColor ("#rrggbb") around the icon, if set.
+type RarityTier struct {
+	Name       string  `json:"name"`
+	MaxPercent float64 `json:"maxPercent"`
+	Sound      string  `json:"sound"`
+	FrameColor string  `json:"frameColor"`
+}
+
+// LogSource describes a log file to tail and how to read unlocks out of it.
+// Pattern is a regular expression whose named groups "game", "achievement"
+// and "time" pick out the parts of a matching line; "achievement" is
+// required. GameID is used when the pattern has no "game" group, and
+// TimeLayout is the Go time layout of the "time" group, which defaults to
+// unix seconds or RFC 3339. Platform names the store or system the game IDs
+// belong to, such as "retroachievements" or "xenia"; only "steam" IDs are
+// looked up on Steam.
+type LogSource struct {
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	Pattern    string `json:"pattern"`
+	GameID     string `json:"gameId"`
+	TimeLayout string `json:"timeLayout"`
+	Platform   string `json:"platform"`
 }
 
 // settingsPath = %localappdata%\Achievement-Thing\settings.json
@@ -25,15 +85,36 @@ func getDefaultFolders() []string {
 		filepath.Join(os.Getenv("APPDATA"), "Steam", "CODEX"),
 		filepath.Join(os.Getenv("APPDATA"), "SmartSteamEmu"),
 		filepath.Join(os.Getenv("APPDATA"), "CreamAPI"),
+		filepath.Join(os.Getenv("APPDATA"), "NemirtingasEpicEmu"),
+		filepath.Join(os.Getenv("APPDATA"), "LumaPlay"),
 		filepath.Join(os.Getenv("PROGRAMDATA"), "Steam"),
 		filepath.Join(os.Getenv("LOCALAPPDATA"), "skidrow"),
+		filepath.Join(os.Getenv("PROGRAMFILES(X86)"), "Steam", "appcache", "stats"),
+	}
+}
+
+func getDefaultProgressThresholds() []int {
+	return []int{25, 50, 75, 90}
+}
+
+func getDefaultRarityTiers() []RarityTier {
+	return []RarityTier{
+		{Name: "Ultra rare", MaxPercent: 1, Sound: "ms-winsoundevent:Notification.Reminder", FrameColor: "#e5b80b"},
+		{Name: "Rare", MaxPercent: 10, Sound: "ms-winsoundevent:Notification.IM", FrameColor: "#8e5bd8"},
+		{Name: "Uncommon", MaxPercent: 35, Sound: "ms-winsoundevent:Notification.AchievementThing", FrameColor: "#3e8ed0"},
+		{Name: "Common", MaxPercent: 100, Sound: "ms-winsoundevent:Notification.AchievementThing"},
 	}
 }
 
 func createDefaultSettings() Settings {
 	var defaultSettings = Settings{
-		ApiKey:  "",
-		Folders: getDefaultFolders(),
+		ApiKey:             "",
+		Folders:            getDefaultFolders(),
+		SteamPath:          filepath.Join(os.Getenv("PROGRAMFILES(X86)"), "Steam"),
+		Language:           "english",
+		ProgressThresholds: getDefaultProgressThresholds(),
+		RarityTiers:        getDefaultRarityTiers(),
+		LenientParsing:     true,
 	}
 	return defaultSettings
 }
@@ -53,6 +134,12 @@ func saveSettings(settings Settings) error {
 	return nil
 }
 
+// SaveSettings writes the settings file. Call watcherservice.ReloadSettings
+// afterwards to apply the changes.
+func SaveSettings(settings Settings) error {
+	return saveSettings(settings)
+}
+
 func LoadSettings() (Settings, error) {
 	if _, err := os.Stat(settingsPath); os.IsNotExist(err) {
 		defaultSettings := createDefaultSettings()
@@ -65,10 +152,19 @@ func LoadSettings() (Settings, error) {
 	if err != nil {
 		return Settings{}, fmt.Errorf("error reading settings file: %w", err)
 	}
-	var loadedSettings Settings
+	// Start from the defaults so settings added in newer versions are filled
+	// in for existing settings files.
+	loadedSettings := createDefaultSettings()
+	// Decoding into the default tiers would leave their sounds and colours
+	// on any tier the user wrote without one, so they only apply when the
+	// file has no tiers at all.
+	loadedSettings.RarityTiers = nil
 	if err := json.Unmarshal(settingsFile, &loadedSettings); err != nil {
 		return Settings{}, fmt.Errorf("error unmarshalling settings: %w", err)
 	}
+	if loadedSettings.RarityTiers == nil {
+		loadedSettings.RarityTiers = getDefaultRarityTiers()
+	}
 	return loadedSettings, nil
 }
 
@@ -0,0 +1,59 @@
+package settingservice
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func loadSettingsFile(t *testing.T, data string) Settings {
+	t.Helper()
+	saved := settingsPath
+	settingsPath = filepath.Join(t.TempDir(), "settings.json")
+	t.Cleanup(func() { settingsPath = saved })
+	if err := os.WriteFile(settingsPath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("LoadSettings: %v", err)
+	}
+	return settings
+}
+
+func TestLoadSettingsRarityTiers(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []RarityTier
+	}{
+		{"absent", `{"apiKey": "key"}`, getDefaultRarityTiers()},
+		{"null", `{"rarityTiers": null}`, getDefaultRarityTiers()},
+		{"empty", `{"rarityTiers": []}`, []RarityTier{}},
+		{
+			"user tiers without sound or colour",
+			`{"rarityTiers": [{"name": "Mythic", "maxPercent": 0.5}, {"name": "Other", "maxPercent": 100, "sound": "custom"}]}`,
+			[]RarityTier{{Name: "Mythic", MaxPercent: 0.5}, {Name: "Other", MaxPercent: 100, Sound: "custom"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := loadSettingsFile(t, tt.data)
+			if !reflect.DeepEqual(settings.RarityTiers, tt.want) {
+				t.Errorf("RarityTiers = %+v, want %+v", settings.RarityTiers, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSettingsFillsNewSettings(t *testing.T) {
+	settings := loadSettingsFile(t, `{"apiKey": "key", "language": "german"}`)
+	if settings.ApiKey != "key" || settings.Language != "german" {
+		t.Errorf("settings from the file were lost: %+v", settings)
+	}
+	if !settings.LenientParsing || !reflect.DeepEqual(settings.ProgressThresholds, getDefaultProgressThresholds()) {
+		t.Errorf("settings missing from the file should keep their defaults: %+v", settings)
+	}
+}
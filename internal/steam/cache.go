@@ -0,0 +1,166 @@
+package steam
+
+import (
+	"Achievement-Thing/pkg/atomicfile"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// cacheFormatVersion is the version of the schema cache files written now.
+// Version 1 files, from before the cache was versioned, hold the bare
+// AchievementsData and are migrated when read.
+const cacheFormatVersion = 2
+
+// schemaMaxAge is how long a cached schema is served before it is
+// revalidated in the background.
+const schemaMaxAge = 90 * 24 * time.Hour
+
+// revalidateRetryInterval keeps a schema that failed to revalidate from
+// being retried on every unlock.
+const revalidateRetryInterval = time.Hour
+
+var errCacheCorrupt = errors.New("cache file is corrupt")
+
+// cacheEntry is a cached schema as stored on disk.
+type cacheEntry struct {
+	Version   int               `json:"version"`
+	FetchedAt time.Time         `json:"fetchedAt"`
+	Data      *AchievementsData `json:"data"`
+}
+
+func (e *cacheEntry) stale() bool {
+	return time.Since(e.FetchedAt) > schemaMaxAge
+}
+
+var revalidations = make(map[string]time.Time)
+var revalidationsMutex sync.Mutex
+
+// readCacheEntry reads a cached schema, migrating files in an older format.
+// A file that can't be decoded is moved aside, so it is fetched again rather
+// than failing every read, and errCacheCorrupt is returned.
+func readCacheEntry(cacheFilePath string) (*cacheEntry, error) {
+	raw, err := os.ReadFile(cacheFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var probe struct {
+		Version int             `json:"version"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return nil, quarantineCache(cacheFilePath, err)
+	}
+
+	switch probe.Version {
+	case 0:
+		var data AchievementsData
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return nil, quarantineCache(cacheFilePath, err)
+		}
+		entry := &cacheEntry{Version: cacheFormatVersion, Data: &data}
+		if info, err := os.Stat(cacheFilePath); err == nil {
+			entry.FetchedAt = info.ModTime()
+		}
+		if err := writeCacheEntry(cacheFilePath, entry); err != nil {
+			fmt.Println("Error migrating cache file:", err)
+		}
+		return entry, nil
+	case cacheFormatVersion:
+		var entry cacheEntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return nil, quarantineCache(cacheFilePath, err)
+		}
+		if entry.Data == nil {
+			return nil, quarantineCache(cacheFilePath, errors.New("no schema data"))
+		}
+		return &entry, nil
+	default:
+		// Written by a newer version of the app. It isn't moved aside, but
+		// callers will fetch the schema again in this version's format.
+		return nil, fmt.Errorf("unsupported cache format version %d in %s", probe.Version, cacheFilePath)
+	}
+}
+
+// quarantineCache moves a corrupt cache file out of the way, keeping it
+// for inspection.
+func quarantineCache(cacheFilePath string, cause error) error {
+	fmt.Println("Corrupt cache file, discarding:", cacheFilePath, cause)
+	if err := os.Rename(cacheFilePath, cacheFilePath+".corrupt"); err != nil {
+		os.Remove(cacheFilePath)
+	}
+	return fmt.Errorf("%w: %s", errCacheCorrupt, cacheFilePath)
+}
+
+func readCache(cacheFilePath string) (*AchievementsData, error) {
+	entry, err := readCacheEntry(cacheFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return entry.Data, nil
+}
+
+func writeCache(cacheFilePath string, achievementsData *AchievementsData) error {
+	return writeCacheEntry(cacheFilePath, &cacheEntry{
+		Version:   cacheFormatVersion,
+		FetchedAt: time.Now(),
+		Data:      achievementsData,
+	})
+}
+
+func writeCacheEntry(cacheFilePath string, entry *cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFile(cacheFilePath, data); err != nil {
+		fmt.Println("Error writing cache file:", err)
+		return err
+	}
+	return nil
+}
+
+// cachedSchema returns an app's schema in the current language. It is
+// cached first if there is no usable cache file, and a stale one is served
+// while it is revalidated in the background.
+func cachedSchema(ctx context.Context, apikey string, appid string) (*AchievementsData, error) {
+	lang := currentLanguage()
+	cacheFilePath := languageCachePath(appid, lang)
+	entry, err := readCacheEntry(cacheFilePath)
+	if err != nil {
+		return cacheSchema(ctx, apikey, appid, lang)
+	}
+	if entry.stale() {
+		revalidate(ctx, apikey, appid, lang)
+	}
+	return entry.Data, nil
+}
+
+// revalidate refreshes a stale schema in the background, unless that is
+// already happening or failed recently.
+func revalidate(ctx context.Context, apikey string, appid string, lang string) {
+	key := appid + "/" + lang
+	revalidationsMutex.Lock()
+	if last, exists := revalidations[key]; exists && time.Since(last) < revalidateRetryInterval {
+		revalidationsMutex.Unlock()
+		return
+	}
+	revalidations[key] = time.Now()
+	revalidationsMutex.Unlock()
+
+	go func() {
+		fmt.Println("Revalidating stale achievement cache for appId:", appid)
+		if _, err := refreshSchema(ctx, apikey, appid, lang); err != nil {
+			fmt.Println("Error revalidating achievement cache, keeping the old one:", err)
+			return
+		}
+		revalidationsMutex.Lock()
+		delete(revalidations, key)
+		revalidationsMutex.Unlock()
+	}()
+}
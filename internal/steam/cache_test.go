@@ -0,0 +1,186 @@
+package steam
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// useSchemaServer points the package at an empty cache and a Web API that
+// answers schema requests with status, returning a count of the requests.
+func useSchemaServer(t *testing.T, status *atomic.Int32) *atomic.Int32 {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if s := int(status.Load()); s != http.StatusOK {
+			w.WriteHeader(s)
+			return
+		}
+		w.Write([]byte(`{"response": {"achievements": [{"internal_name": "ACH_WIN", "localized_name": "Winner"}]}}`))
+	}))
+	savedClient, savedDir := currentClient(), cacheDir
+	SetClient(&Client{BaseURL: server.URL, HTTPClient: server.Client()})
+	cacheDir = t.TempDir()
+	recentCacheOperationsMutex.Lock()
+	clear(recentCacheOperations)
+	recentCacheOperationsMutex.Unlock()
+	revalidationsMutex.Lock()
+	clear(revalidations)
+	revalidationsMutex.Unlock()
+	t.Cleanup(func() {
+		server.Close()
+		SetClient(savedClient)
+		cacheDir = savedDir
+	})
+	return &calls
+}
+
+func writeTestCache(t *testing.T, path string, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadCacheEntryMigratesVersion1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "achievements.json")
+	writeTestCache(t, path, `{"appid": "480", "achievements": [{"internal_name": "ACH_WIN", "localized_name": "Winner"}]}`)
+	modTime := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := readCacheEntry(path)
+	if err != nil {
+		t.Fatalf("readCacheEntry: %v", err)
+	}
+	if entry.Data.AppID != "480" || len(entry.Data.Achievements) != 1 || !entry.FetchedAt.Equal(modTime) {
+		t.Errorf("entry = %+v", entry)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var onDisk cacheEntry
+	if err := json.Unmarshal(raw, &onDisk); err != nil || onDisk.Version != cacheFormatVersion || !onDisk.FetchedAt.Equal(modTime) {
+		t.Errorf("migrated file = %s, %v", raw, err)
+	}
+}
+
+func TestReadCacheEntryQuarantinesCorruptFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"truncated", `{"version": 2, "data": {"appid": "48`},
+		{"not JSON", "\x00\x01\x02"},
+		{"no data", `{"version": 2, "fetchedAt": "2024-01-01T00:00:00Z"}`},
+		{"bad version 1 file", `{"appid": 480}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "achievements.json")
+			writeTestCache(t, path, tt.data)
+
+			if _, err := readCacheEntry(path); !errors.Is(err, errCacheCorrupt) {
+				t.Errorf("error = %v, want errCacheCorrupt", err)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Error("the corrupt file should have been moved aside")
+			}
+			if data, err := os.ReadFile(path + ".corrupt"); err != nil || string(data) != tt.data {
+				t.Errorf("quarantined file = %q, %v", data, err)
+			}
+		})
+	}
+}
+
+func TestReadCacheEntryKeepsNewerVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "achievements.json")
+	writeTestCache(t, path, `{"version": 99, "data": {}}`)
+
+	if _, err := readCacheEntry(path); err == nil || errors.Is(err, errCacheCorrupt) {
+		t.Errorf("error = %v, want an unsupported version error", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("a file from a newer version should be left alone: %v", err)
+	}
+}
+
+func TestCachedSchemaRevalidatesStaleCache(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	calls := useSchemaServer(t, &status)
+
+	path := languageCachePath("900001", DefaultLanguage)
+	stale := cacheEntry{
+		Version:   cacheFormatVersion,
+		FetchedAt: time.Now().Add(-schemaMaxAge - time.Hour),
+		Data:      &AchievementsData{AppID: "900001", Achievements: []Achievement{{ApiName: "ACH_OLD"}}},
+	}
+	if err := writeCacheEntry(path, &stale); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := cachedSchema(context.Background(), "key", "900001")
+	if err != nil || data.Achievements[0].ApiName != "ACH_OLD" {
+		t.Fatalf("the stale schema should be served while it is revalidated: %+v, %v", data, err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if entry, err := readCacheEntry(path); err == nil && !entry.stale() {
+			if entry.Data.Achievements[0].ApiName != "ACH_WIN" {
+				t.Errorf("revalidated schema = %+v", entry.Data)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the stale schema was never revalidated")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("%d requests, want 1", got)
+	}
+}
+
+func TestCachedSchemaDuringCooldown(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusForbidden)
+	calls := useSchemaServer(t, &status)
+
+	if _, err := cachedSchema(context.Background(), "key", "900002"); err == nil {
+		t.Fatal("expected the failed fetch's error")
+	}
+	// Within the cooldown the fetch isn't repeated, and having no schema yet
+	// is reported as such.
+	status.Store(http.StatusOK)
+	if _, err := cachedSchema(context.Background(), "key", "900002"); err != errSchemaPending {
+		t.Errorf("error during the cooldown = %v, want errSchemaPending", err)
+	}
+	if err := CacheAchievements(context.Background(), "key", "900002"); err != nil {
+		t.Errorf("CacheAchievements during the cooldown = %v", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("%d requests, want 1", got)
+	}
+
+	// A fetch that succeeds hands back its schema without a second read.
+	data, err := cachedSchema(context.Background(), "key", "900003")
+	if err != nil || len(data.Achievements) != 1 {
+		t.Errorf("cachedSchema = %+v, %v", data, err)
+	}
+}
@@ -0,0 +1,312 @@
+package steam
+
+import (
+	"Achievement-Thing/pkg/atomicfile"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBaseURL    = "https://api.steampowered.com"
+	DefaultCDNBaseURL = "https://steamcdn-a.akamaihd.net/steamcommunity/public/images/apps"
+	// DefaultDailyQuota is the number of Web API calls Steam allows a key
+	// per day.
+	DefaultDailyQuota = 100000
+)
+
+var ErrQuotaExceeded = errors.New("daily Steam Web API quota reached")
+
+// Client talks to the Steam Web API and CDN. Requests that fail with a 429
+// or 5xx status, or with a network error, are retried with exponential
+// backoff; Web API calls count against a per-day quota. API keys never
+// appear in the errors it returns.
+type Client struct {
+	BaseURL    string
+	CDNBaseURL string
+	HTTPClient *http.Client
+	// MaxRetries is how many times a failed request is retried, waiting
+	// Backoff before the first retry and twice as long before each next one.
+	MaxRetries int
+	Backoff    time.Duration
+	DailyQuota int
+	// QuotaFile is where the day's call count is kept, so the quota holds
+	// across restarts. Without one the count is only kept in memory.
+	QuotaFile string
+
+	quotaMutex  sync.Mutex
+	quotaLoaded bool
+	quotaDay    string
+	quotaUsed   int
+}
+
+// quotaState is the day's call count as stored in the quota file.
+type quotaState struct {
+	Day  string `json:"day"`
+	Used int    `json:"used"`
+}
+
+// NewClient returns a client for the real Steam endpoints.
+func NewClient() *Client {
+	return &Client{
+		BaseURL:    DefaultBaseURL,
+		CDNBaseURL: DefaultCDNBaseURL,
+		HTTPClient: &http.Client{Timeout: 15 * time.Second},
+		MaxRetries: 3,
+		Backoff:    time.Second,
+		DailyQuota: DefaultDailyQuota,
+		QuotaFile:  filepath.Join(cacheDir, "quota.json"),
+	}
+}
+
+var client = NewClient()
+var clientMutex sync.RWMutex
+
+// SetClient replaces the client the package functions use, for instance
+// with one pointed at a test server.
+func SetClient(c *Client) {
+	clientMutex.Lock()
+	defer clientMutex.Unlock()
+	client = c
+}
+
+func currentClient() *Client {
+	clientMutex.RLock()
+	defer clientMutex.RUnlock()
+	return client
+}
+
+// GetGameAchievements fetches an app's achievement schema from the Web API in
+// a language, given by its Steam API name, with icon names turned into CDN
+// URLs.
+func (c *Client) GetGameAchievements(ctx context.Context, apikey string, appid string, language string) (*AchievementsData, error) {
+	query := url.Values{}
+	query.Set("language", language)
+	query.Set("key", apikey)
+	query.Set("appid", appid)
+
+	var apiResponse struct {
+		Response struct {
+			Achievements []Achievement `json:"achievements"`
+		} `json:"response"`
+	}
+	if err := c.getJSON(ctx, "/IPlayerService/GetGameAchievements/v1/?"+query.Encode(), &apiResponse); err != nil {
+		return nil, err
+	}
+
+	achievements := apiResponse.Response.Achievements
+	for i := range achievements {
+		achievements[i].Icon = c.iconURL(appid, achievements[i].Icon)
+		achievements[i].IconGray = c.iconURL(appid, achievements[i].IconGray)
+	}
+	return &AchievementsData{AppID: appid, Achievements: achievements}, nil
+}
+
+// GetGlobalAchievementPercentages fetches the share of players, in percent,
+// that has unlocked each of an app's achievements, keyed by API name.
+func (c *Client) GetGlobalAchievementPercentages(ctx context.Context, appid string) (map[string]float64, error) {
+	query := url.Values{}
+	query.Set("gameid", appid)
+
+	var apiResponse struct {
+		AchievementPercentages struct {
+			Achievements []struct {
+				Name    string      `json:"name"`
+				Percent json.Number `json:"percent"`
+			} `json:"achievements"`
+		} `json:"achievementpercentages"`
+	}
+	if err := c.getJSON(ctx, "/ISteamUserStats/GetGlobalAchievementPercentagesForApp/v2/?"+query.Encode(), &apiResponse); err != nil {
+		return nil, err
+	}
+
+	percentages := make(map[string]float64)
+	for _, a := range apiResponse.AchievementPercentages.Achievements {
+		if percent, err := a.Percent.Float64(); err == nil {
+			percentages[a.Name] = percent
+		}
+	}
+	return percentages, nil
+}
+
+// iconURL turns an icon file name from the schema into its CDN URL.
+func (c *Client) iconURL(appid string, icon string) string {
+	if icon == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/%s", c.CDNBaseURL, appid, icon)
+}
+
+// getJSON calls a Web API method, given as a path and query, and decodes
+// the response into v.
+func (c *Client) getJSON(ctx context.Context, path string, v any) error {
+	if err := c.useQuota(); err != nil {
+		return err
+	}
+	resp, err := c.get(ctx, c.BaseURL+path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("error decoding Steam API response: %v", err)
+	}
+	return nil
+}
+
+// Download fetches a file, typically an image from the CDN, into w.
+func (c *Client) Download(ctx context.Context, rawURL string, w io.Writer) error {
+	resp, err := c.get(ctx, rawURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("error downloading %s: %v", redactKey(rawURL), err)
+	}
+	return nil
+}
+
+// get performs a GET request, retrying failures that may be temporary. The
+// response it returns always has a 200 status.
+func (c *Client) get(ctx context.Context, rawURL string) (*http.Response, error) {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	backoff := c.Backoff
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return nil, errors.New(redactKey(err.Error()))
+		}
+
+		resp, err := httpClient.Do(req)
+		var wait time.Duration
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			err = errors.New(redactKey(err.Error()))
+		case resp.StatusCode == http.StatusOK:
+			return resp, nil
+		default:
+			resp.Body.Close()
+			err = fmt.Errorf("request to %s failed: %s", redactKey(rawURL), resp.Status)
+			if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+				return nil, err
+			}
+			wait = retryAfter(resp)
+		}
+
+		if attempt >= c.MaxRetries {
+			return nil, err
+		}
+		if wait == 0 {
+			wait = backoff
+		}
+		fmt.Println("Steam request failed, retrying in", wait.String()+":", err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		backoff *= 2
+	}
+}
+
+// retryAfter reads the delay a 429 or 503 response asks for, if any.
+func retryAfter(resp *http.Response) time.Duration {
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return 0
+}
+
+// useQuota counts a Web API call against today's quota, failing once the
+// quota is used up. The count resets at midnight UTC, as Steam's does.
+func (c *Client) useQuota() error {
+	if c.DailyQuota <= 0 {
+		return nil
+	}
+	c.quotaMutex.Lock()
+	defer c.quotaMutex.Unlock()
+	c.loadQuota()
+
+	today := time.Now().UTC().Format(time.DateOnly)
+	if c.quotaDay != today {
+		c.quotaDay = today
+		c.quotaUsed = 0
+	}
+	if c.quotaUsed >= c.DailyQuota {
+		return ErrQuotaExceeded
+	}
+	c.quotaUsed++
+	c.saveQuota()
+	return nil
+}
+
+// QuotaUsed returns the number of Web API calls made today.
+func (c *Client) QuotaUsed() int {
+	c.quotaMutex.Lock()
+	defer c.quotaMutex.Unlock()
+	c.loadQuota()
+	if c.quotaDay != time.Now().UTC().Format(time.DateOnly) {
+		return 0
+	}
+	return c.quotaUsed
+}
+
+// loadQuota reads the count left by an earlier run, the first time the
+// quota is needed. The caller holds quotaMutex.
+func (c *Client) loadQuota() {
+	if c.quotaLoaded || c.QuotaFile == "" {
+		return
+	}
+	c.quotaLoaded = true
+	data, err := os.ReadFile(c.QuotaFile)
+	if err != nil {
+		return
+	}
+	var state quotaState
+	if err := json.Unmarshal(data, &state); err != nil {
+		fmt.Println("Error reading Steam API quota file:", err)
+		return
+	}
+	c.quotaDay, c.quotaUsed = state.Day, state.Used
+}
+
+// saveQuota writes the count out. The caller holds quotaMutex.
+func (c *Client) saveQuota() {
+	if c.QuotaFile == "" {
+		return
+	}
+	data, err := json.Marshal(quotaState{Day: c.quotaDay, Used: c.quotaUsed})
+	if err == nil {
+		err = atomicfile.WriteFile(c.QuotaFile, data)
+	}
+	if err != nil {
+		fmt.Println("Error writing Steam API quota file:", err)
+	}
+}
+
+var apiKeyParam = regexp.MustCompile(`(?i)([?&]key=)[^&\s"]*`)
+
+// redactKey hides the API key in a URL, or in an error message quoting one.
+func redactKey(s string) string {
+	return apiKeyParam.ReplaceAllString(s, "${1}REDACTED")
+}
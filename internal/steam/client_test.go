@@ -0,0 +1,262 @@
+package steam
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testKey = "0123456789ABCDEF"
+
+// newTestClient returns a client for a server that answers with statuses in
+// turn, repeating the last one, and a count of the requests it received.
+func newTestClient(t *testing.T, statuses ...int) (*Client, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		status := statuses[min(n, len(statuses))-1]
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte(`{"response": {"achievements": [{"internal_name": "ACH_WIN", "localized_name": "Winner", "icon": "win.jpg"}]}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	c := &Client{
+		BaseURL:    server.URL,
+		CDNBaseURL: server.URL + "/images",
+		HTTPClient: server.Client(),
+		MaxRetries: 2,
+		Backoff:    time.Millisecond,
+		DailyQuota: 10,
+	}
+	return c, &calls
+}
+
+// captureOutput returns what f prints to stdout.
+func captureOutput(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+	f()
+	w.Close()
+	return <-output
+}
+
+func TestClientRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantCalls int32
+		wantErr   bool
+	}{
+		{"success", []int{200}, 1, false},
+		{"retried server error", []int{503, 500, 200}, 3, false},
+		{"retried rate limit", []int{429, 200}, 2, false},
+		{"retries exhausted", []int{503}, 3, true},
+		{"not retried", []int{404}, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, calls := newTestClient(t, tt.statuses...)
+			data, err := c.GetGameAchievements(context.Background(), testKey, "480", "english")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetGameAchievements error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("%d requests, want %d", got, tt.wantCalls)
+			}
+			if err == nil && (len(data.Achievements) != 1 || data.Achievements[0].Icon != c.CDNBaseURL+"/480/win.jpg") {
+				t.Errorf("achievements = %+v", data.Achievements)
+			}
+		})
+	}
+}
+
+func TestClientBackoff(t *testing.T) {
+	c, _ := newTestClient(t, 503, 503, 200)
+	c.Backoff = 20 * time.Millisecond
+
+	start := time.Now()
+	if _, err := c.GetGameAchievements(context.Background(), testKey, "480", "english"); err != nil {
+		t.Fatal(err)
+	}
+	// 20ms before the first retry and 40ms before the second.
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("retries took %v, want at least 60ms", elapsed)
+	}
+}
+
+func TestClientRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	c := &Client{BaseURL: server.URL, HTTPClient: server.Client(), MaxRetries: 1, Backoff: time.Millisecond}
+
+	start := time.Now()
+	if _, err := c.GetGlobalAchievementPercentages(context.Background(), "480"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want the second Retry-After asked for", elapsed)
+	}
+}
+
+func TestClientCancelled(t *testing.T) {
+	c, calls := newTestClient(t, 503)
+	c.Backoff = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err := c.GetGameAchievements(ctx, testKey, "480", "english")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("%d requests, want 1", got)
+	}
+}
+
+func TestClientQuota(t *testing.T) {
+	c, calls := newTestClient(t, 200)
+	c.DailyQuota = 2
+	c.QuotaFile = filepath.Join(t.TempDir(), "quota.json")
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetGameAchievements(context.Background(), testKey, "480", "english"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := c.GetGameAchievements(context.Background(), testKey, "480", "english"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("error = %v, want ErrQuotaExceeded", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("%d requests, want 2", got)
+	}
+	if got := c.QuotaUsed(); got != 2 {
+		t.Errorf("QuotaUsed = %d, want 2", got)
+	}
+
+	// The count carries over to a new client, as after a restart.
+	restarted := &Client{BaseURL: c.BaseURL, HTTPClient: c.HTTPClient, DailyQuota: 2, QuotaFile: c.QuotaFile}
+	if got := restarted.QuotaUsed(); got != 2 {
+		t.Errorf("QuotaUsed after a restart = %d, want 2", got)
+	}
+	if _, err := restarted.GetGameAchievements(context.Background(), testKey, "480", "english"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("error after a restart = %v, want ErrQuotaExceeded", err)
+	}
+
+	// A count from an earlier day doesn't count.
+	if err := os.WriteFile(c.QuotaFile, []byte(`{"day": "2000-01-01", "used": 2}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	nextDay := &Client{BaseURL: c.BaseURL, HTTPClient: c.HTTPClient, DailyQuota: 2, QuotaFile: c.QuotaFile}
+	if _, err := nextDay.GetGameAchievements(context.Background(), testKey, "480", "english"); err != nil {
+		t.Errorf("error on a new day = %v", err)
+	}
+}
+
+func TestClientRedactsKey(t *testing.T) {
+	c, _ := newTestClient(t, 503)
+	var err error
+	output := captureOutput(t, func() {
+		_, err = c.GetGameAchievements(context.Background(), testKey, "480", "english")
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if strings.Contains(err.Error(), testKey) || !strings.Contains(err.Error(), "key=REDACTED") {
+		t.Errorf("error = %q", err)
+	}
+	if !strings.Contains(output, "retrying") || strings.Contains(output, testKey) {
+		t.Errorf("logged %q", output)
+	}
+
+	// Network errors quote the URL too.
+	c.BaseURL = "http://127.0.0.1:0"
+	c.MaxRetries = 0
+	_, err = c.GetGameAchievements(context.Background(), testKey, "480", "english")
+	if err == nil || strings.Contains(err.Error(), testKey) {
+		t.Errorf("network error = %v", err)
+	}
+}
+
+func TestRedactKey(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://api/x?key=SECRET&appid=480", "https://api/x?key=REDACTED&appid=480"},
+		{"https://api/x?appid=480&KEY=SECRET", "https://api/x?appid=480&KEY=REDACTED"},
+		{`Get "https://api/x?key=SECRET": timeout`, `Get "https://api/x?key=REDACTED": timeout`},
+		{"https://api/x?monkey=1", "https://api/x?monkey=1"},
+	}
+	for _, tt := range tests {
+		if got := redactKey(tt.in); got != tt.want {
+			t.Errorf("redactKey(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGetImage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/images/480/win.jpg" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("jpeg"))
+	}))
+	defer server.Close()
+	savedClient, savedDir := currentClient(), cacheDir
+	SetClient(&Client{CDNBaseURL: server.URL + "/images", HTTPClient: server.Client()})
+	cacheDir = t.TempDir()
+	defer func() {
+		SetClient(savedClient)
+		cacheDir = savedDir
+	}()
+
+	path, err := GetImage(context.Background(), "480", server.URL+"/images/480/win.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "jpeg" {
+		t.Errorf("image = %q, %v", data, err)
+	}
+
+	if _, err := GetImage(context.Background(), "480", server.URL+"/images/480/missing.jpg"); err == nil {
+		t.Error("expected an error for a missing image")
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("image folder holds %d files, want only the image", len(entries))
+	}
+}
@@ -0,0 +1,309 @@
+package steam
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Games running under the Goldberg emulator ship a steam_settings folder
+// next to the game with an achievement schema and, usually, the icons it
+// refers to. These are found either from an achievement file that lives
+// inside the game folder or by scanning the configured game folders.
+
+const steamSettingsIndexTTL = 10 * time.Minute
+
+// maxGameFolderDepth bounds how deep game folders are scanned for
+// steam_settings folders.
+const maxGameFolderDepth = 6
+
+var gameFolders []string
+
+// steamSettingsDirs holds the folders found next to achievement files, and
+// steamSettingsIndex those found by the last scan of the game folders.
+// steamSettingsStamp records the game folders' modification times at that
+// scan, so a scan only runs again once a game has been added or removed.
+var steamSettingsDirs = make(map[string]string)
+var steamSettingsIndex map[string]string
+var steamSettingsStamp string
+var steamSettingsChecked time.Time
+var steamSettingsMutex sync.Mutex
+
+func init() {
+	schemaProviders = append([]SchemaProvider{goldbergSchema}, schemaProviders...)
+}
+
+// SetGameFolders sets the folders scanned for games that ship a Goldberg
+// steam_settings folder.
+func SetGameFolders(folders []string) {
+	steamSettingsMutex.Lock()
+	defer steamSettingsMutex.Unlock()
+	gameFolders = folders
+	steamSettingsIndex = nil
+	steamSettingsStamp = ""
+	steamSettingsChecked = time.Time{}
+}
+
+// RememberSteamSettings looks for a steam_settings folder in the folders
+// containing path, typically an achievement file saved inside the game
+// folder, and remembers it as the one for appid.
+func RememberSteamSettings(appid string, path string) {
+	for dir := filepath.Dir(path); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		settingsDir := filepath.Join(dir, "steam_settings")
+		if info, err := os.Stat(settingsDir); err != nil || !info.IsDir() {
+			continue
+		}
+		if id := steamSettingsAppId(settingsDir); id != "" && id != appid {
+			continue
+		}
+		steamSettingsMutex.Lock()
+		steamSettingsDirs[appid] = settingsDir
+		steamSettingsMutex.Unlock()
+		return
+	}
+}
+
+// steamSettingsAppId reads the app ID a steam_settings folder belongs to,
+// from steam_appid.txt inside it or next to it.
+func steamSettingsAppId(settingsDir string) string {
+	for _, p := range []string{
+		filepath.Join(settingsDir, "steam_appid.txt"),
+		filepath.Join(filepath.Dir(settingsDir), "steam_appid.txt"),
+	} {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		if id := strings.TrimSpace(string(data)); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+func findSteamSettings(appid string) (string, error) {
+	steamSettingsMutex.Lock()
+	if dir, ok := steamSettingsDirs[appid]; ok {
+		steamSettingsMutex.Unlock()
+		return dir, nil
+	}
+	if dir, ok := steamSettingsIndex[appid]; ok {
+		steamSettingsMutex.Unlock()
+		return dir, nil
+	}
+	due := time.Since(steamSettingsChecked) > steamSettingsIndexTTL
+	if due {
+		steamSettingsChecked = time.Now()
+	}
+	folders, stamp := gameFolders, steamSettingsStamp
+	steamSettingsMutex.Unlock()
+
+	notFound := fmt.Errorf("no steam_settings folder found for appId %s", appid)
+	if !due {
+		return "", notFound
+	}
+
+	// The scan can take a while, so it runs without the lock and is skipped
+	// while the game folders look the same as at the last one.
+	newStamp := gameFoldersStamp(folders)
+	if newStamp == stamp {
+		return "", notFound
+	}
+	index := indexGameFolders(folders)
+
+	steamSettingsMutex.Lock()
+	defer steamSettingsMutex.Unlock()
+	if !slices.Equal(gameFolders, folders) {
+		return "", notFound
+	}
+	steamSettingsIndex = index
+	steamSettingsStamp = newStamp
+	if dir, ok := index[appid]; ok {
+		return dir, nil
+	}
+	return "", notFound
+}
+
+// gameFoldersStamp sums up the modification times of the game folders,
+// which change when a game is installed into or removed from one of them.
+func gameFoldersStamp(folders []string) string {
+	var b strings.Builder
+	for _, folder := range folders {
+		if info, err := os.Stat(folder); err == nil {
+			b.WriteString(strconv.FormatInt(info.ModTime().UnixNano(), 10))
+		}
+		b.WriteByte(';')
+	}
+	return b.String()
+}
+
+// indexGameFolders scans folders for steam_settings folders, returning them
+// by app ID.
+func indexGameFolders(folders []string) map[string]string {
+	index := make(map[string]string)
+	for _, folder := range folders {
+		baseDepth := strings.Count(filepath.Clean(folder), string(os.PathSeparator))
+		filepath.WalkDir(folder, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return nil
+			}
+			if strings.Count(path, string(os.PathSeparator))-baseDepth > maxGameFolderDepth {
+				return filepath.SkipDir
+			}
+			if !strings.EqualFold(d.Name(), "steam_settings") {
+				return nil
+			}
+			if id := steamSettingsAppId(path); id != "" {
+				if _, exists := index[id]; !exists {
+					index[id] = path
+				}
+			}
+			return filepath.SkipDir
+		})
+	}
+	return index
+}
+
+type goldbergAchievement struct {
+	Name        string          `json:"name"`
+	DisplayName json.RawMessage `json:"displayName"`
+	Description json.RawMessage `json:"description"`
+	Hidden      json.RawMessage `json:"hidden"`
+	Icon        string          `json:"icon"`
+	IconGray    string          `json:"icongray"`
+	IconGrayAlt string          `json:"icon_gray"`
+}
+
+// goldbergSchema reads the achievement schema from a game's Goldberg
+// steam_settings folder. Icons point at the image files bundled with it.
+func goldbergSchema(appid string, language string) (*AchievementsData, error) {
+	settingsDir, err := findSteamSettings(appid)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(settingsDir, "achievements.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []goldbergAchievement
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("error parsing steam_settings schema for appId %s: %w", appid, err)
+	}
+
+	achievementsData := &AchievementsData{AppID: appid}
+	for _, e := range entries {
+		if e.Name == "" {
+			continue
+		}
+		iconGray := e.IconGray
+		if iconGray == "" {
+			iconGray = e.IconGrayAlt
+		}
+		achievementsData.Achievements = append(achievementsData.Achievements, Achievement{
+			ApiName:     e.Name,
+			DisplayName: localizedJSON(e.DisplayName, language),
+			Description: localizedJSON(e.Description, language),
+			Icon:        bundledIcon(settingsDir, e.Icon),
+			IconGray:    bundledIcon(settingsDir, iconGray),
+			Hidden:      jsonTruthy(e.Hidden),
+		})
+	}
+	return achievementsData, nil
+}
+
+// StatTypes returns the type of each stat, "int", "float" or "avgrate", by
+// name, from the stats.txt in a game's Goldberg steam_settings folder. Each
+// line of it reads name=type=default. It returns nil if there is none.
+func StatTypes(appid string) map[string]string {
+	settingsDir, err := findSteamSettings(appid)
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(settingsDir, "stats.txt"))
+	if err != nil {
+		return nil
+	}
+
+	types := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		parts := strings.Split(strings.TrimSpace(line), "=")
+		if len(parts) < 2 || parts[0] == "" {
+			continue
+		}
+		types[parts[0]] = strings.ToLower(strings.TrimSpace(parts[1]))
+	}
+	return types
+}
+
+// bundledIcon resolves an icon path from a steam_settings schema, which is
+// relative to the steam_settings folder, and checks the file is there.
+func bundledIcon(settingsDir string, icon string) string {
+	if icon == "" {
+		return ""
+	}
+	candidates := []string{
+		filepath.Join(settingsDir, filepath.FromSlash(icon)),
+		filepath.Join(settingsDir, "images", filepath.Base(filepath.FromSlash(icon))),
+	}
+	for _, p := range candidates {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// localizedJSON reads a schema string that is either plain or an object
+// keyed by language name, falling back to English and then to the first
+// language by name.
+func localizedJSON(raw json.RawMessage, language string) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var byLanguage map[string]string
+	if json.Unmarshal(raw, &byLanguage) != nil {
+		return ""
+	}
+	for _, lang := range []string{language, "english"} {
+		if s := byLanguage[lang]; s != "" {
+			return s
+		}
+	}
+	// Go maps have no order, so the fallback goes by language name to pick
+	// the same string every time.
+	languages := make([]string, 0, len(byLanguage))
+	for lang := range byLanguage {
+		languages = append(languages, lang)
+	}
+	slices.Sort(languages)
+	for _, lang := range languages {
+		if s := byLanguage[lang]; s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+func jsonTruthy(raw json.RawMessage) bool {
+	s := strings.Trim(strings.TrimSpace(string(raw)), `"`)
+	if b, err := strconv.ParseBool(s); err == nil {
+		return b
+	}
+	n, err := strconv.Atoi(s)
+	return err == nil && n != 0
+}
+
+// isLocalImage reports whether an icon refers to a file on disk rather than
+// a URL, as bundled Goldberg icons do.
+func isLocalImage(icon string) bool {
+	return !strings.HasPrefix(icon, "http://") && !strings.HasPrefix(icon, "https://")
+}
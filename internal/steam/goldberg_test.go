@@ -0,0 +1,177 @@
+package steam
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// useGameFolders points the steam_settings lookup at folders, starting
+// from an empty index.
+func useGameFolders(t *testing.T, folders ...string) {
+	t.Helper()
+	steamSettingsMutex.Lock()
+	savedFolders, savedDirs := gameFolders, steamSettingsDirs
+	steamSettingsDirs = make(map[string]string)
+	steamSettingsMutex.Unlock()
+	SetGameFolders(folders)
+	t.Cleanup(func() {
+		SetGameFolders(savedFolders)
+		steamSettingsMutex.Lock()
+		steamSettingsDirs = savedDirs
+		steamSettingsMutex.Unlock()
+	})
+}
+
+// writeSteamSettings creates a game folder under root with a steam_settings
+// folder for appid holding files, by path relative to it.
+func writeSteamSettings(t *testing.T, root string, game string, appid string, files map[string]string) string {
+	t.Helper()
+	dir := filepath.Join(root, game, "steam_settings")
+	files["steam_appid.txt"] = appid + "\n"
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestGoldbergSchema(t *testing.T) {
+	root := t.TempDir()
+	dir := writeSteamSettings(t, root, "Spacewar", "480", map[string]string{
+		"achievements.json": `[
+			{"name": "ACH_WIN", "displayName": {"english": "Winner", "french": "Gagnant"}, "description": "Win a match",
+			 "hidden": "0", "icon": "images/win.jpg", "icon_gray": "images/win_gray.jpg"},
+			{"name": "ACH_SECRET", "displayName": {"german": "Geheimnis"}, "hidden": 1, "icon": "missing.jpg"},
+			{"displayName": "No API name"}
+		]`,
+		"images/win.jpg":      "jpg",
+		"images/win_gray.jpg": "jpg",
+		"stats.txt":           "kills=int=0\r\naccuracy=Float=0.5\n\nbroken\n",
+	})
+	useGameFolders(t, root)
+
+	data, err := goldbergSchema("480", "french")
+	if err != nil {
+		t.Fatalf("goldbergSchema: %v", err)
+	}
+	if len(data.Achievements) != 2 {
+		t.Fatalf("got %d achievements, want 2: %+v", len(data.Achievements), data.Achievements)
+	}
+	win := data.Achievements[0]
+	if win.ApiName != "ACH_WIN" || win.DisplayName != "Gagnant" || win.Description != "Win a match" || win.Hidden ||
+		win.Icon != filepath.Join(dir, "images", "win.jpg") || win.IconGray != filepath.Join(dir, "images", "win_gray.jpg") {
+		t.Errorf("ACH_WIN = %+v", win)
+	}
+	if secret := data.Achievements[1]; secret.DisplayName != "Geheimnis" || !secret.Hidden || secret.Icon != "" {
+		t.Errorf("ACH_SECRET = %+v", secret)
+	}
+
+	types := StatTypes("480")
+	if len(types) != 2 || types["kills"] != "int" || types["accuracy"] != "float" {
+		t.Errorf("StatTypes = %v", types)
+	}
+
+	if _, err := goldbergSchema("481", "english"); err == nil {
+		t.Error("expected an error for an app without steam_settings")
+	}
+	if types := StatTypes("481"); types != nil {
+		t.Errorf("StatTypes without steam_settings = %v", types)
+	}
+}
+
+func TestBundledIcon(t *testing.T) {
+	dir := writeSteamSettings(t, t.TempDir(), "Spacewar", "480", map[string]string{
+		"win.jpg":         "jpg",
+		"images/lose.jpg": "jpg",
+	})
+
+	tests := []struct {
+		icon string
+		want string
+	}{
+		{"win.jpg", filepath.Join(dir, "win.jpg")},
+		{"images/lose.jpg", filepath.Join(dir, "images", "lose.jpg")},
+		// Icons listed without their folder are looked up in images.
+		{"lose.jpg", filepath.Join(dir, "images", "lose.jpg")},
+		{"other/lose.jpg", filepath.Join(dir, "images", "lose.jpg")},
+		{"missing.jpg", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := bundledIcon(dir, tt.icon); got != tt.want {
+			t.Errorf("bundledIcon(%q) = %q, want %q", tt.icon, got, tt.want)
+		}
+	}
+}
+
+func TestLocalizedJSON(t *testing.T) {
+	tests := []struct {
+		raw      string
+		language string
+		want     string
+	}{
+		{`"Plain"`, "french", "Plain"},
+		{`{"english": "Winner", "french": "Gagnant"}`, "french", "Gagnant"},
+		{`{"english": "Winner", "french": ""}`, "french", "Winner"},
+		{`{"spanish": "Secreto", "german": "Geheimnis", "italian": ""}`, "french", "Geheimnis"},
+		{`42`, "english", ""},
+		{``, "english", ""},
+	}
+	for _, tt := range tests {
+		// Run each case a few times, as map order changes between runs.
+		for range 5 {
+			if got := localizedJSON([]byte(tt.raw), tt.language); got != tt.want {
+				t.Errorf("localizedJSON(%s, %s) = %q, want %q", tt.raw, tt.language, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestFindSteamSettingsRescansChangedFolders(t *testing.T) {
+	root := t.TempDir()
+	writeSteamSettings(t, root, "Spacewar", "480", map[string]string{})
+	useGameFolders(t, root)
+
+	expire := func() {
+		steamSettingsMutex.Lock()
+		steamSettingsChecked = time.Time{}
+		steamSettingsMutex.Unlock()
+	}
+
+	if _, err := findSteamSettings("480"); err != nil {
+		t.Fatalf("findSteamSettings: %v", err)
+	}
+
+	// A game nested inside an existing folder leaves the game folder's
+	// modification time alone, so it isn't scanned for again.
+	writeSteamSettings(t, filepath.Join(root, "Spacewar"), "DLC", "481", map[string]string{})
+	expire()
+	if dir, err := findSteamSettings("481"); err == nil {
+		t.Errorf("found %s without a change to the game folder", dir)
+	}
+
+	// A newly installed game changes it, and the next lookup scans again.
+	want := writeSteamSettings(t, root, "Other", "482", map[string]string{})
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(root, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if dir, err := findSteamSettings("482"); err == nil {
+		t.Errorf("rescanned before the interval was up and found %s", dir)
+	}
+	expire()
+	if dir, err := findSteamSettings("482"); err != nil || dir != want {
+		t.Errorf("findSteamSettings = %q, %v; want %q", dir, err, want)
+	}
+	if _, err := findSteamSettings("480"); err != nil {
+		t.Errorf("lost 480 after the rescan: %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package steam
+
+import (
+	"Achievement-Thing/pkg/keyvalues"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+// SchemaProvider loads achievement metadata for an app from local files, for
+// when there is no API key or the Web API can't be reached. Strings missing
+// in the requested language fall back to English.
+type SchemaProvider func(appid string, language string) (*AchievementsData, error)
+
+var schemaProviders = []SchemaProvider{steamClientSchema}
+
+// defaultSteamPath is where the Steam client installs itself by default.
+var defaultSteamPath = filepath.Join(os.Getenv("PROGRAMFILES(X86)"), "Steam")
+
+var steamPath = defaultSteamPath
+var steamPathMutex sync.RWMutex
+
+// SetSteamPath sets the Steam install whose appcache is read for local
+// achievement schemas. An empty path goes back to the default install.
+func SetSteamPath(path string) {
+	steamPathMutex.Lock()
+	defer steamPathMutex.Unlock()
+	if path == "" {
+		path = defaultSteamPath
+	}
+	steamPath = path
+}
+
+func currentSteamPath() string {
+	steamPathMutex.RLock()
+	defer steamPathMutex.RUnlock()
+	return steamPath
+}
+
+func loadLocalSchema(appid string, language string) (*AchievementsData, error) {
+	err := errors.New("no local achievement schema found")
+	for _, provider := range schemaProviders {
+		data, providerErr := provider(appid, language)
+		if providerErr == nil && len(data.Achievements) > 0 {
+			return data, nil
+		}
+		if providerErr != nil {
+			err = providerErr
+		}
+	}
+	return nil, err
+}
+
+// steamClientSchema reads the UserGameStatsSchema file the Steam client keeps
+// for every game it has run, which holds the same display names,
+// descriptions and icons as the Web API.
+func steamClientSchema(appid string, language string) (*AchievementsData, error) {
+	schemaPath := filepath.Join(currentSteamPath(), "appcache", "stats", "UserGameStatsSchema_"+appid+".bin")
+	data, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return nil, err
+	}
+	return parseClientSchema(appid, data, language)
+}
+
+func parseClientSchema(appid string, data []byte, language string) (*AchievementsData, error) {
+	root, err := keyvalues.ParseBinary(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing schema for appId %s: %w", appid, err)
+	}
+	if len(root.Children) == 0 {
+		return nil, fmt.Errorf("empty schema for appId %s", appid)
+	}
+
+	achievementsData := &AchievementsData{AppID: appid, GameName: root.Children[0].Child("gamename").String()}
+	stats := root.Children[0].Child("stats")
+	if stats == nil {
+		return achievementsData, nil
+	}
+	for _, stat := range stats.Children {
+		// Only achievement stats have bits; the others are plain stats.
+		bits := stat.Child("bits")
+		if bits == nil {
+			continue
+		}
+		for _, bit := range bits.Children {
+			name := bit.Child("name").String()
+			if name == "" {
+				continue
+			}
+			display := bit.Child("display")
+			hidden, _ := display.Child("hidden").Int()
+			achievementsData.Achievements = append(achievementsData.Achievements, Achievement{
+				ApiName:     name,
+				DisplayName: localized(display.Child("name"), language),
+				Description: localized(display.Child("desc"), language),
+				Icon:        iconURL(appid, display.Child("icon").String()),
+				IconGray:    iconURL(appid, display.Child("icon_gray").String()),
+				Hidden:      hidden != 0,
+			})
+		}
+	}
+	return achievementsData, nil
+}
+
+// localized picks a language out of a schema string, which is either a plain
+// string or a section keyed by language name. It falls back to English and
+// then to whatever language is there.
+func localized(node *keyvalues.Node, language string) string {
+	if node == nil {
+		return ""
+	}
+	if node.Type != keyvalues.TypeNone {
+		return node.String()
+	}
+	for _, lang := range []string{language, "english"} {
+		if s := node.Child(lang).String(); s != "" {
+			return s
+		}
+	}
+	for _, c := range node.Children {
+		if s := c.String(); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+var appManifestName = regexp.MustCompile(`(?m)^\s*"name"\s+"([^"]*)"`)
+
+// GetGameName returns the name of a game if it is known locally, from the
+// cached schema or the Steam client's app manifest, or "" otherwise.
+func GetGameName(appid string) string {
+	if data, err := readCache(schemaCachePath(appid)); err == nil && data.GameName != "" {
+		return data.GameName
+	}
+
+	manifest, err := os.ReadFile(filepath.Join(currentSteamPath(), "steamapps", "appmanifest_"+appid+".acf"))
+	if err != nil {
+		return ""
+	}
+	if m := appManifestName.FindSubmatch(manifest); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
@@ -0,0 +1,194 @@
+package steam
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Binary KeyValues builders for UserGameStatsSchema fixtures.
+
+func kvInt(name string, v int32) []byte {
+	out := append([]byte{0x02}, name...)
+	return binary.LittleEndian.AppendUint32(append(out, 0), uint32(v))
+}
+
+func kvString(name string, v string) []byte {
+	out := append([]byte{0x01}, name...)
+	return append(append(append(out, 0), v...), 0)
+}
+
+func kvSection(name string, children ...[]byte) []byte {
+	out := append([]byte{0x00}, name...)
+	out = append(out, 0)
+	for _, c := range children {
+		out = append(out, c...)
+	}
+	return append(out, 0x08)
+}
+
+func kvDocument(sections ...[]byte) []byte {
+	var out []byte
+	for _, s := range sections {
+		out = append(out, s...)
+	}
+	return append(out, 0x08)
+}
+
+var clientSchemaFixture = kvDocument(kvSection("480",
+	kvString("gamename", "Spacewar"),
+	kvSection("stats",
+		kvSection("1",
+			kvInt("type", 4),
+			kvSection("bits",
+				kvSection("0",
+					kvString("name", "ACH_WIN"),
+					kvSection("display",
+						kvSection("name", kvString("english", "Winner"), kvString("french", "Gagnant")),
+						kvSection("desc", kvString("english", "Win a match")),
+						kvString("icon", "win.jpg"),
+						kvString("icon_gray", "win_gray.jpg"),
+					),
+				),
+				kvSection("1",
+					kvString("name", "ACH_SECRET"),
+					kvSection("display",
+						kvSection("name", kvString("german", "Geheimnis"), kvString("spanish", "Secreto")),
+						kvString("desc", "Plain description"),
+						kvInt("hidden", 1),
+					),
+				),
+				kvSection("2", kvSection("display", kvString("name", "No API name"))),
+			),
+		),
+		kvSection("2", kvInt("type", 1), kvString("name", "kills")),
+	),
+))
+
+// useSteamPath points the package at a fake Steam install holding schema
+// files for the given apps.
+func useSteamPath(t *testing.T, schemas map[string][]byte) {
+	t.Helper()
+	dir := t.TempDir()
+	stats := filepath.Join(dir, "appcache", "stats")
+	if err := os.MkdirAll(stats, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for appid, data := range schemas {
+		if err := os.WriteFile(filepath.Join(stats, "UserGameStatsSchema_"+appid+".bin"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	saved := currentSteamPath()
+	SetSteamPath(dir)
+	t.Cleanup(func() { SetSteamPath(saved) })
+}
+
+func TestSteamClientSchema(t *testing.T) {
+	useSteamPath(t, map[string][]byte{"480": clientSchemaFixture})
+
+	data, err := steamClientSchema("480", "french")
+	if err != nil {
+		t.Fatalf("steamClientSchema: %v", err)
+	}
+	if data.AppID != "480" || data.GameName != "Spacewar" {
+		t.Errorf("app %q, game name %q", data.AppID, data.GameName)
+	}
+	if len(data.Achievements) != 2 {
+		t.Fatalf("got %d achievements, want 2: %+v", len(data.Achievements), data.Achievements)
+	}
+
+	win := data.Achievements[0]
+	if win.ApiName != "ACH_WIN" || win.DisplayName != "Gagnant" || win.Description != "Win a match" || win.Hidden {
+		t.Errorf("ACH_WIN = %+v", win)
+	}
+	if win.Icon != iconURL("480", "win.jpg") || win.IconGray != iconURL("480", "win_gray.jpg") {
+		t.Errorf("ACH_WIN icons = %q, %q", win.Icon, win.IconGray)
+	}
+
+	secret := data.Achievements[1]
+	if secret.DisplayName != "Geheimnis" || secret.Description != "Plain description" || !secret.Hidden || secret.Icon != "" {
+		t.Errorf("ACH_SECRET = %+v", secret)
+	}
+
+	if _, err := steamClientSchema("481", "english"); err == nil {
+		t.Error("expected an error for an app without a schema file")
+	}
+}
+
+func TestParseClientSchemaMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"truncated", clientSchemaFixture[:20]},
+		{"empty document", kvDocument()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseClientSchema("480", tt.data, "english"); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	// A game without stats has an empty schema rather than a broken one.
+	data, err := parseClientSchema("480", kvDocument(kvSection("480", kvString("gamename", "Spacewar"))), "english")
+	if err != nil || data.GameName != "Spacewar" || len(data.Achievements) != 0 {
+		t.Errorf("schema without stats = %+v, %v", data, err)
+	}
+}
+
+func TestLocalized(t *testing.T) {
+	root, err := parseClientSchema("480", clientSchemaFixture, "english")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Achievements[0].DisplayName != "Winner" {
+		t.Errorf("english name = %q", root.Achievements[0].DisplayName)
+	}
+
+	tests := []struct {
+		language string
+		want     string
+	}{
+		{"french", "Gagnant"},
+		{"english", "Winner"},
+		// Missing languages fall back to English.
+		{"japanese", "Winner"},
+	}
+	for _, tt := range tests {
+		data, err := parseClientSchema("480", clientSchemaFixture, tt.language)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := data.Achievements[0].DisplayName; got != tt.want {
+			t.Errorf("%s: name = %q, want %q", tt.language, got, tt.want)
+		}
+	}
+
+	// Without English either, the first language in the file is used.
+	data, _ := parseClientSchema("480", clientSchemaFixture, "french")
+	if got := data.Achievements[1].DisplayName; got != "Geheimnis" {
+		t.Errorf("fallback name = %q, want the first language listed", got)
+	}
+	if got := localized(nil, "english"); got != "" {
+		t.Errorf("localized(nil) = %q", got)
+	}
+}
+
+func TestSetSteamPath(t *testing.T) {
+	saved := currentSteamPath()
+	t.Cleanup(func() { SetSteamPath(saved) })
+
+	SetSteamPath("D:\\Steam")
+	if got := currentSteamPath(); got != "D:\\Steam" {
+		t.Errorf("steam path = %q", got)
+	}
+	SetSteamPath("")
+	if got := currentSteamPath(); got != defaultSteamPath {
+		t.Errorf("after reset, steam path = %q, want %q", got, defaultSteamPath)
+	}
+}
@@ -0,0 +1,160 @@
+package steam
+
+import (
+	"Achievement-Thing/pkg/atomicfile"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rarityCacheExpiry is how long global unlock percentages are kept. They
+// change far more often than schemas do.
+const rarityCacheExpiry = 24 * time.Hour
+
+// rarityRetryInterval keeps percentages that failed to load from being
+// fetched again on every unlock.
+const rarityRetryInterval = 10 * time.Minute
+
+// rarityFetches holds when each app's percentages were last fetched, for
+// fetches that are running or have failed.
+var rarityFetches = make(map[string]time.Time)
+var rarityFetchesMutex sync.Mutex
+
+type rarityCache struct {
+	AppID       string             `json:"appid"`
+	Percentages map[string]float64 `json:"percentages"`
+}
+
+func rarityCachePath(appid string) string {
+	return filepath.Join(cacheDir, appid, "rarity.json")
+}
+
+// GetRarity returns the share of players, in percent, that has unlocked each
+// of an app's achievements, keyed by API name. The percentages are fetched
+// once the cached ones expire; if that fails, the expired ones or those in
+// the cached schema are used instead. A failed fetch isn't retried for a
+// while.
+func GetRarity(ctx context.Context, appid string) (map[string]float64, error) {
+	cached, fresh, cacheErr := cachedRarity(appid)
+	if fresh {
+		return cached, nil
+	}
+
+	percentages, err := fetchRarity(ctx, appid)
+	if err == nil {
+		return percentages, nil
+	}
+	if cacheErr == nil {
+		return cached, nil
+	}
+	if fromSchema := schemaRarity(appid); len(fromSchema) > 0 {
+		return fromSchema, nil
+	}
+	return nil, err
+}
+
+// GetAchievementRarity returns the share of players, in percent, that has
+// unlocked an achievement, if it is known. It only reads what is cached, so
+// it never waits on the network; expired or missing percentages are fetched
+// in the background for the next unlock.
+func GetAchievementRarity(ctx context.Context, appid string, achievementName string) (float64, bool) {
+	percentages, fresh, err := cachedRarity(appid)
+	if !fresh {
+		go func() {
+			if _, err := fetchRarity(ctx, appid); err != nil && err != errRarityFetchSkipped {
+				fmt.Println("Error refreshing achievement rarity:", err)
+			}
+		}()
+	}
+	if err != nil {
+		percentages = schemaRarity(appid)
+	}
+	percent, ok := percentages[achievementName]
+	return percent, ok
+}
+
+// cachedRarity reads an app's cached percentages and whether they are
+// still fresh.
+func cachedRarity(appid string) (map[string]float64, bool, error) {
+	cacheFilePath := rarityCachePath(appid)
+	cached, err := readRarityCache(cacheFilePath)
+	if err != nil {
+		return nil, false, err
+	}
+	info, err := os.Stat(cacheFilePath)
+	fresh := err == nil && time.Since(info.ModTime()) < rarityCacheExpiry
+	return cached, fresh, nil
+}
+
+var errRarityFetchSkipped = errors.New("achievement rarity was fetched recently")
+
+// fetchRarity fetches an app's percentages into the cache, unless that is
+// already happening or failed recently.
+func fetchRarity(ctx context.Context, appid string) (map[string]float64, error) {
+	rarityFetchesMutex.Lock()
+	if last, exists := rarityFetches[appid]; exists && time.Since(last) < rarityRetryInterval {
+		rarityFetchesMutex.Unlock()
+		return nil, errRarityFetchSkipped
+	}
+	rarityFetches[appid] = time.Now()
+	rarityFetchesMutex.Unlock()
+
+	percentages, err := currentClient().GetGlobalAchievementPercentages(ctx, appid)
+	if err == nil && len(percentages) == 0 {
+		err = fmt.Errorf("no achievement percentages for appId %s", appid)
+	}
+	if err != nil {
+		fmt.Println("Error fetching achievement rarity:", err)
+		return nil, err
+	}
+	if err := writeRarityCache(rarityCachePath(appid), appid, percentages); err != nil {
+		fmt.Println("Error writing rarity cache:", err)
+	}
+
+	rarityFetchesMutex.Lock()
+	delete(rarityFetches, appid)
+	rarityFetchesMutex.Unlock()
+	return percentages, nil
+}
+
+// schemaRarity reads the percentages some schema responses carry along,
+// which are as old as the cached schema.
+func schemaRarity(appid string) map[string]float64 {
+	achievementsData, err := readCache(schemaCachePath(appid))
+	if err != nil {
+		return nil
+	}
+	percentages := make(map[string]float64)
+	for _, a := range achievementsData.Achievements {
+		if percent, err := strconv.ParseFloat(a.Rarity, 64); err == nil {
+			percentages[a.ApiName] = percent
+		}
+	}
+	return percentages
+}
+
+func readRarityCache(cacheFilePath string) (map[string]float64, error) {
+	data, err := os.ReadFile(cacheFilePath)
+	if err != nil {
+		return nil, err
+	}
+	var cache rarityCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, err
+	}
+	return cache.Percentages, nil
+}
+
+func writeRarityCache(cacheFilePath string, appid string, percentages map[string]float64) error {
+	data, err := json.Marshal(rarityCache{AppID: appid, Percentages: percentages})
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(cacheFilePath, data)
+}
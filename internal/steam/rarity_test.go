@@ -0,0 +1,89 @@
+package steam
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// useRarityServer points the package at a server answering with status and
+// an empty cache, returning a count of the requests it received.
+func useRarityServer(t *testing.T, status *atomic.Int32) *atomic.Int32 {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if s := int(status.Load()); s != http.StatusOK {
+			w.WriteHeader(s)
+			return
+		}
+		w.Write([]byte(`{"achievementpercentages": {"achievements": [{"name": "ACH_WIN", "percent": "4.5"}]}}`))
+	}))
+	savedClient, savedDir := currentClient(), cacheDir
+	SetClient(&Client{BaseURL: server.URL, HTTPClient: server.Client()})
+	cacheDir = t.TempDir()
+	rarityFetchesMutex.Lock()
+	clear(rarityFetches)
+	rarityFetchesMutex.Unlock()
+	t.Cleanup(func() {
+		server.Close()
+		SetClient(savedClient)
+		cacheDir = savedDir
+	})
+	return &calls
+}
+
+func TestGetAchievementRarityRefreshesInBackground(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	calls := useRarityServer(t, &status)
+
+	if _, ok := GetAchievementRarity(context.Background(), "480", "ACH_WIN"); ok {
+		t.Error("nothing is cached yet, so the rarity should be unknown")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if percent, ok := GetAchievementRarity(context.Background(), "480", "ACH_WIN"); ok {
+			if percent != 4.5 {
+				t.Errorf("rarity = %v, want 4.5", percent)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the rarity was never cached")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("%d requests, want 1", got)
+	}
+}
+
+func TestGetRarityCachesFailures(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusForbidden)
+	calls := useRarityServer(t, &status)
+
+	if _, err := GetRarity(context.Background(), "480"); err == nil {
+		t.Fatal("expected an error")
+	}
+	status.Store(http.StatusOK)
+	if _, err := GetRarity(context.Background(), "480"); err == nil {
+		t.Error("a recent failure should not be retried yet")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("%d requests, want 1", got)
+	}
+
+	// Once the retry interval has passed, the fetch is tried again.
+	rarityFetchesMutex.Lock()
+	rarityFetches["480"] = time.Now().Add(-rarityRetryInterval)
+	rarityFetchesMutex.Unlock()
+	percentages, err := GetRarity(context.Background(), "480")
+	if err != nil || percentages["ACH_WIN"] != 4.5 {
+		t.Errorf("GetRarity = %v, %v", percentages, err)
+	}
+}
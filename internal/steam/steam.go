@@ -1,13 +1,14 @@
 package steam
 
 import (
-	"encoding/json"
+	"Achievement-Thing/pkg/atomicfile"
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
@@ -19,6 +20,44 @@ var recentCacheOperationsMutex sync.RWMutex
 
 const cacheCooldown = 5 * time.Second
 
+// DefaultLanguage is the language schemas fall back to for strings that
+// aren't translated.
+const DefaultLanguage = "english"
+
+var language = DefaultLanguage
+var languageMutex sync.RWMutex
+
+// SetLanguage sets the language achievement names and descriptions are
+// shown in, by its Steam API name such as "german" or "brazilian".
+func SetLanguage(lang string) {
+	languageMutex.Lock()
+	defer languageMutex.Unlock()
+	if lang == "" {
+		lang = DefaultLanguage
+	}
+	language = strings.ToLower(lang)
+}
+
+func currentLanguage() string {
+	languageMutex.RLock()
+	defer languageMutex.RUnlock()
+	return language
+}
+
+// schemaCachePath is where an app's schema is cached in the current
+// language. English keeps the original file name, so existing caches stay
+// valid.
+func schemaCachePath(appid string) string {
+	return languageCachePath(appid, currentLanguage())
+}
+
+func languageCachePath(appid string, lang string) string {
+	if lang == DefaultLanguage {
+		return filepath.Join(cacheDir, appid, "achievements.json")
+	}
+	return filepath.Join(cacheDir, appid, "achievements_"+lang+".json")
+}
+
 func isOlderThanMonths(filepath string, months int) (bool, error) {
 	info, err := os.Stat(filepath)
 	if err != nil {
@@ -40,116 +79,157 @@ type Achievement struct {
 
 type AchievementsData struct {
 	AppID        string        `json:"appid"`
+	GameName     string        `json:"gameName,omitempty"`
 	Achievements []Achievement `json:"achievements"`
 }
 
-func CacheAchievements(apikey string, appid string) error {
+// errSchemaPending is returned for an app whose schema was just being
+// cached, but isn't on disk: that attempt is still running or failed.
+var errSchemaPending = errors.New("achievement schema is not cached yet")
+
+func CacheAchievements(ctx context.Context, apikey string, appid string) error {
+	_, err := cacheSchema(ctx, apikey, appid, currentLanguage())
+	if err == errSchemaPending {
+		return nil
+	}
+	return err
+}
+
+// cacheSchema makes sure an app's schema in a language is cached and
+// returns it. Within cacheCooldown of the last attempt it only reads what is
+// cached.
+func cacheSchema(ctx context.Context, apikey string, appid string, lang string) (*AchievementsData, error) {
 	fmt.Println("Caching achievements for appId:", appid)
-	if apikey == "" || appid == "" {
-		return errors.New("API key or App ID is empty")
+	if appid == "" {
+		return nil, errors.New("App ID is empty")
 	}
 
+	cacheFilePath := languageCachePath(appid, lang)
 	recentCacheOperationsMutex.Lock()
-	if lastOp, exists := recentCacheOperations[appid]; exists {
+	if lastOp, exists := recentCacheOperations[appid+"/"+lang]; exists {
 		if time.Since(lastOp) < cacheCooldown {
 			recentCacheOperationsMutex.Unlock()
-			return nil
+			if achievementsData, err := readCache(cacheFilePath); err == nil {
+				return achievementsData, nil
+			}
+			return nil, errSchemaPending
 		}
 	}
-	recentCacheOperations[appid] = time.Now()
+	recentCacheOperations[appid+"/"+lang] = time.Now()
 	recentCacheOperationsMutex.Unlock()
 
-	cacheFilePath := filepath.Join(cacheDir, appid, "achievements.json")
-	// Check if cache file exists and is recent
-	if _, err := os.Stat(cacheFilePath); err == nil {
-		isOld, err := isOlderThanMonths(cacheFilePath, 3)
-		if err != nil {
-			fmt.Println("Error checking cache file age:", err)
-			return err
-		}
-		if !isOld {
+	if entry, err := readCacheEntry(cacheFilePath); err == nil {
+		if entry.stale() {
+			revalidate(ctx, apikey, appid, lang)
+		} else {
 			fmt.Println("Cache file is recent, skipping fetch for appId:", appid)
-			return nil
 		}
-
-	}
-
-	url := "https://api.steampowered.com/IPlayerService/GetGameAchievements/v1/?language=english&key=" + apikey + "&appid=" + appid
-	// fmt.Println("Fetching achievements from URL:", url)
-
-	if err := os.MkdirAll(filepath.Dir(cacheFilePath), 0755); err != nil {
-		fmt.Println("Error creating cache directory:", err)
-		return err
+		return entry.Data, nil
 	}
+	return refreshSchema(ctx, apikey, appid, lang)
+}
 
-	resp, err := http.Get(url)
+// refreshSchema loads an app's schema in a language and caches it.
+func refreshSchema(ctx context.Context, apikey string, appid string, lang string) (*AchievementsData, error) {
+	achievementsData, err := loadSchema(ctx, apikey, appid, lang)
 	if err != nil {
-		fmt.Println("Error fetching data from API:", err)
-		return err
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		fmt.Println("Non-OK HTTP status:", resp.StatusCode)
-		return errors.New("failed to fetch data from API")
+	if lang != DefaultLanguage {
+		// The Web API leaves untranslated strings empty rather than
+		// giving the English ones.
+		english, err := readCache(languageCachePath(appid, DefaultLanguage))
+		if err != nil {
+			english, err = loadSchema(ctx, apikey, appid, DefaultLanguage)
+		}
+		if err == nil {
+			fillMissingStrings(achievementsData, english)
+		} else {
+			fmt.Println("Error loading English achievements for fallback:", err)
+		}
 	}
 
-	var apiResponse struct {
-		Response struct {
-			Achievements []Achievement `json:"achievements"`
-		} `json:"response"`
+	if err := writeCache(languageCachePath(appid, lang), achievementsData); err != nil {
+		return nil, err
 	}
+	notifySchemaCached(appid)
+	return achievementsData, nil
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
-		fmt.Println("Error decoding API response:", err)
-		return err
-	}
+var schemaCachedHandlers []func(appid string)
+var schemaCachedHandlersMutex sync.RWMutex
 
-	for i := range apiResponse.Response.Achievements {
-		if apiResponse.Response.Achievements[i].Icon != "" {
-			apiResponse.Response.Achievements[i].Icon = fmt.Sprintf("https://steamcdn-a.akamaihd.net/steamcommunity/public/images/apps/%s/%s", appid, apiResponse.Response.Achievements[i].Icon)
-		}
-		if apiResponse.Response.Achievements[i].IconGray != "" {
-			apiResponse.Response.Achievements[i].IconGray = fmt.Sprintf("https://steamcdn-a.akamaihd.net/steamcommunity/public/images/apps/%s/%s", appid, apiResponse.Response.Achievements[i].IconGray)
-		}
-	}
+// OnSchemaCached registers a handler that is called, in a goroutine of its
+// own, whenever an app's schema has been loaded and cached.
+func OnSchemaCached(handler func(appid string)) {
+	schemaCachedHandlersMutex.Lock()
+	defer schemaCachedHandlersMutex.Unlock()
+	schemaCachedHandlers = append(schemaCachedHandlers, handler)
+}
 
-	achievementsData := AchievementsData{
-		AppID:        appid,
-		Achievements: apiResponse.Response.Achievements,
+func notifySchemaCached(appid string) {
+	schemaCachedHandlersMutex.RLock()
+	defer schemaCachedHandlersMutex.RUnlock()
+	for _, handler := range schemaCachedHandlers {
+		go handler(appid)
 	}
+}
 
-	file, err := os.Create(cacheFilePath)
-	if err != nil {
-		fmt.Println("Error creating cache file:", err)
-		return err
+// loadSchema finds an app's schema in a language. Schemas found on disk
+// come first: they need no key and bundled icons don't have to be
+// downloaded. The Web API covers everything else.
+func loadSchema(ctx context.Context, apikey string, appid string, lang string) (*AchievementsData, error) {
+	achievementsData, err := loadLocalSchema(appid, lang)
+	if err == nil {
+		return achievementsData, nil
 	}
-	defer file.Close()
-
-	if err := json.NewEncoder(file).Encode(achievementsData); err != nil {
-		fmt.Println("Error writing to cache file:", err)
-		return err
+	if apikey == "" {
+		return nil, err
 	}
-
-	return nil
+	return fetchAchievements(ctx, apikey, appid, lang)
 }
 
-func GetAchievement(appid string, achievementName string, apikey string) (*Achievement, error) {
-	cacheFilePath := filepath.Join(cacheDir, appid, "achievements.json")
-	if _, err := os.Stat(cacheFilePath); os.IsNotExist(err) {
-		if err := CacheAchievements(apikey, appid); err != nil {
-			return nil, err
+// fillMissingStrings copies the English name and description of every
+// achievement that has none in data's language.
+func fillMissingStrings(data *AchievementsData, english *AchievementsData) {
+	byName := make(map[string]Achievement, len(english.Achievements))
+	for _, a := range english.Achievements {
+		byName[a.ApiName] = a
+	}
+	for i, a := range data.Achievements {
+		fallback, ok := byName[a.ApiName]
+		if !ok {
+			continue
+		}
+		if a.DisplayName == "" {
+			data.Achievements[i].DisplayName = fallback.DisplayName
+		}
+		if a.Description == "" {
+			data.Achievements[i].Description = fallback.Description
 		}
 	}
+	if data.GameName == "" {
+		data.GameName = english.GameName
+	}
+}
 
-	file, err := os.Open(cacheFilePath)
+func fetchAchievements(ctx context.Context, apikey string, appid string, lang string) (*AchievementsData, error) {
+	achievementsData, err := currentClient().GetGameAchievements(ctx, apikey, appid, lang)
 	if err != nil {
+		fmt.Println("Error fetching data from API:", err)
 		return nil, err
 	}
-	defer file.Close()
+	return achievementsData, nil
+}
 
-	var achievementsData AchievementsData
-	if err := json.NewDecoder(file).Decode(&achievementsData); err != nil {
+// iconURL turns an icon file name from the schema into its CDN URL.
+func iconURL(appid string, icon string) string {
+	return currentClient().iconURL(appid, icon)
+}
+
+func GetAchievement(ctx context.Context, appid string, achievementName string, apikey string) (*Achievement, error) {
+	achievementsData, err := cachedSchema(ctx, apikey, appid)
+	if err != nil {
 		return nil, err
 	}
 
@@ -162,7 +242,29 @@ func GetAchievement(appid string, achievementName string, apikey string) (*Achie
 	return nil, errors.New("achievement not found")
 }
 
-func GetImage(appid string, imageURL string) (string, error) {
+// AchievementNames returns the API names of every achievement in an app's
+// cached schema, caching it first if needed.
+func AchievementNames(ctx context.Context, apikey string, appid string) ([]string, error) {
+	achievementsData, err := cachedSchema(ctx, apikey, appid)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(achievementsData.Achievements))
+	for _, achievement := range achievementsData.Achievements {
+		names = append(names, achievement.ApiName)
+	}
+	return names, nil
+}
+
+func GetImage(ctx context.Context, appid string, imageURL string) (string, error) {
+	if isLocalImage(imageURL) {
+		if _, err := os.Stat(imageURL); err != nil {
+			return "", errors.New("image file not found")
+		}
+		return imageURL, nil
+	}
+
 	imageDir := filepath.Join(cacheDir, appid, "images")
 	if err := os.MkdirAll(imageDir, 0755); err != nil {
 		return "", err
@@ -179,23 +281,13 @@ func GetImage(appid string, imageURL string) (string, error) {
 		}
 	}
 
-	resp, err := http.Get(imageURL)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", errors.New("failed to fetch image")
-	}
-
-	file, err := os.Create(imagePath)
-	if err != nil {
+	// Downloaded into memory and written atomically, so a failed refresh
+	// keeps the old image and concurrent downloads don't share a file.
+	var image bytes.Buffer
+	if err := currentClient().Download(ctx, imageURL, &image); err != nil {
 		return "", err
 	}
-	defer file.Close()
-
-	if _, err := io.Copy(file, resp.Body); err != nil {
+	if err := atomicfile.WriteFile(imagePath, image.Bytes()); err != nil {
 		return "", err
 	}
 
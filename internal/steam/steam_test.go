@@ -0,0 +1,138 @@
+package steam
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLanguageCachePath(t *testing.T) {
+	saved := cacheDir
+	cacheDir = "cache"
+	t.Cleanup(func() { cacheDir = saved })
+
+	tests := []struct {
+		lang string
+		want string
+	}{
+		// English keeps the file name caches had before languages.
+		{DefaultLanguage, filepath.Join("cache", "480", "achievements.json")},
+		{"german", filepath.Join("cache", "480", "achievements_german.json")},
+		{"schinese", filepath.Join("cache", "480", "achievements_schinese.json")},
+	}
+	for _, tt := range tests {
+		if got := languageCachePath("480", tt.lang); got != tt.want {
+			t.Errorf("languageCachePath(480, %s) = %q, want %q", tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestFillMissingStrings(t *testing.T) {
+	data := &AchievementsData{Achievements: []Achievement{
+		{ApiName: "ACH_WIN", DisplayName: "Gewinner", Description: "Gewinne ein Spiel"},
+		{ApiName: "ACH_LOSE", DisplayName: "Verlierer"},
+		{ApiName: "ACH_TRAVEL"},
+		{ApiName: "ACH_GERMAN_ONLY"},
+	}}
+	english := &AchievementsData{GameName: "Spacewar", Achievements: []Achievement{
+		{ApiName: "ACH_WIN", DisplayName: "Winner", Description: "Win a match"},
+		{ApiName: "ACH_LOSE", DisplayName: "Loser", Description: "Lose a match"},
+		{ApiName: "ACH_TRAVEL", DisplayName: "Traveller", Description: "Travel far"},
+	}}
+
+	fillMissingStrings(data, english)
+
+	want := []Achievement{
+		{ApiName: "ACH_WIN", DisplayName: "Gewinner", Description: "Gewinne ein Spiel"},
+		{ApiName: "ACH_LOSE", DisplayName: "Verlierer", Description: "Lose a match"},
+		{ApiName: "ACH_TRAVEL", DisplayName: "Traveller", Description: "Travel far"},
+		{ApiName: "ACH_GERMAN_ONLY"},
+	}
+	for i, a := range data.Achievements {
+		if a != want[i] {
+			t.Errorf("achievement %d = %+v, want %+v", i, a, want[i])
+		}
+	}
+	if data.GameName != "Spacewar" {
+		t.Errorf("game name = %q", data.GameName)
+	}
+}
+
+// useLanguageServer points the package at an empty cache and a Web API that
+// has a translation for only one of two achievements, returning a count of
+// the requests for each language.
+func useLanguageServer(t *testing.T) map[string]*atomic.Int32 {
+	t.Helper()
+	calls := map[string]*atomic.Int32{DefaultLanguage: new(atomic.Int32), "german": new(atomic.Int32)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := r.URL.Query().Get("language")
+		calls[lang].Add(1)
+		if lang == "german" {
+			w.Write([]byte(`{"response": {"achievements": [
+				{"internal_name": "ACH_WIN", "localized_name": "Gewinner", "localized_desc": ""},
+				{"internal_name": "ACH_LOSE", "localized_name": ""}]}}`))
+			return
+		}
+		w.Write([]byte(`{"response": {"achievements": [
+			{"internal_name": "ACH_WIN", "localized_name": "Winner", "localized_desc": "Win a match"},
+			{"internal_name": "ACH_LOSE", "localized_name": "Loser", "localized_desc": "Lose a match"}]}}`))
+	}))
+	savedClient, savedDir := currentClient(), cacheDir
+	SetClient(&Client{BaseURL: server.URL, HTTPClient: server.Client()})
+	cacheDir = t.TempDir()
+	t.Cleanup(func() {
+		server.Close()
+		SetClient(savedClient)
+		cacheDir = savedDir
+	})
+	return calls
+}
+
+func TestRefreshSchemaFillsFromEnglish(t *testing.T) {
+	calls := useLanguageServer(t)
+
+	data, err := refreshSchema(context.Background(), "key", "900010", "german")
+	if err != nil {
+		t.Fatalf("refreshSchema: %v", err)
+	}
+	want := []Achievement{
+		{ApiName: "ACH_WIN", DisplayName: "Gewinner", Description: "Win a match"},
+		{ApiName: "ACH_LOSE", DisplayName: "Loser", Description: "Lose a match"},
+	}
+	for i, a := range data.Achievements {
+		if a != want[i] {
+			t.Errorf("achievement %d = %+v, want %+v", i, a, want[i])
+		}
+	}
+
+	// What is cached is the filled-in schema.
+	cached, err := readCache(languageCachePath("900010", "german"))
+	if err != nil || cached.Achievements[1].DisplayName != "Loser" {
+		t.Errorf("cached schema = %+v, %v", cached, err)
+	}
+	if calls["german"].Load() != 1 || calls[DefaultLanguage].Load() != 1 {
+		t.Errorf("requests: german %d, english %d; want 1 each", calls["german"].Load(), calls[DefaultLanguage].Load())
+	}
+}
+
+func TestRefreshSchemaUsesCachedEnglish(t *testing.T) {
+	calls := useLanguageServer(t)
+
+	if _, err := refreshSchema(context.Background(), "key", "900011", DefaultLanguage); err != nil {
+		t.Fatalf("refreshSchema in English: %v", err)
+	}
+	data, err := refreshSchema(context.Background(), "key", "900011", "german")
+	if err != nil {
+		t.Fatalf("refreshSchema: %v", err)
+	}
+	if data.Achievements[0].Description != "Win a match" {
+		t.Errorf("ACH_WIN = %+v", data.Achievements[0])
+	}
+	// English came from the cache the second time round.
+	if got := calls[DefaultLanguage].Load(); got != 1 {
+		t.Errorf("%d English requests, want 1", got)
+	}
+}
@@ -0,0 +1,88 @@
+package watcherservice
+
+import (
+	"Achievement-Thing/internal/parser"
+	"sync"
+	"time"
+)
+
+type EventKind string
+
+const (
+	EventUnlocked EventKind = "unlocked"
+	EventProgress EventKind = "progress"
+	EventStat     EventKind = "stat"
+	// EventMetadata means earlier events for AppID got their display
+	// metadata filled in and should be reloaded from History.
+	EventMetadata EventKind = "metadata"
+	// EventUnresolved means the file at Path was queued because its app ID
+	// couldn't be worked out; see UnresolvedFiles.
+	EventUnresolved EventKind = "unresolved"
+)
+
+// Event is something the watcher noticed in an achievement or stats file.
+type Event struct {
+	Kind        EventKind          `json:"kind"`
+	Profile     string             `json:"profile"`
+	AppID       string             `json:"appId"`
+	Achievement parser.Achievement `json:"achievement"`
+	Stat        parser.Stat        `json:"stat"`
+	Time        time.Time          `json:"time"`
+	// Source is the emulator or tool the unlock was read from, if known.
+	Source string `json:"source,omitempty"`
+	// Path is the file an unresolved event is about.
+	Path string `json:"path,omitempty"`
+
+	DisplayName string `json:"displayName"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+	// Grade is the trophy grade for platforms that have them.
+	Grade string `json:"grade,omitempty"`
+	// Rarity is the share of players, in percent, that has unlocked the
+	// achievement, when it is known.
+	Rarity *float64 `json:"rarity,omitempty"`
+	// Tier is the name of the rarity tier the unlock falls in.
+	Tier string `json:"tier,omitempty"`
+	// Degraded is set when no achievement schema was available and the
+	// display fields were made up from the API name.
+	Degraded bool `json:"degraded"`
+}
+
+const maxHistory = 500
+
+var eventHandlers []func(Event)
+var history []Event
+var eventsMutex sync.RWMutex
+
+// OnEvent registers a handler that is called for every watcher event.
+func OnEvent(handler func(Event)) {
+	eventsMutex.Lock()
+	defer eventsMutex.Unlock()
+	eventHandlers = append(eventHandlers, handler)
+}
+
+// History returns the most recent watcher events, oldest first.
+func History() []Event {
+	eventsMutex.RLock()
+	defer eventsMutex.RUnlock()
+	return append([]Event(nil), history...)
+}
+
+func emit(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	eventsMutex.Lock()
+	history = append(history, event)
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+	handlers := make([]func(Event), len(eventHandlers))
+	copy(handlers, eventHandlers)
+	eventsMutex.Unlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
@@ -0,0 +1,160 @@
+package watcherservice
+
+import (
+	"Achievement-Thing/internal/parser"
+	"Achievement-Thing/internal/settingservice"
+	"Achievement-Thing/pkg/logtail"
+	"fmt"
+	"maps"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const logPollInterval = time.Second
+
+// logSource is a configured log file with its compiled rule.
+type logSource struct {
+	settingservice.LogSource
+	pattern *regexp.Regexp
+	tailer  *logtail.Tailer
+}
+
+// logUnlock is an unlock read from a log line.
+type logUnlock struct {
+	gameId      string
+	achievement string
+	time        time.Time
+}
+
+func newLogSources(configs []settingservice.LogSource) []*logSource {
+	var sources []*logSource
+	for _, config := range configs {
+		pattern, err := regexp.Compile(config.Pattern)
+		if err != nil {
+			fmt.Println("Invalid pattern for log source", config.Name+":", err)
+			continue
+		}
+		if pattern.SubexpIndex("achievement") < 0 {
+			fmt.Println("Log source", config.Name, "has no achievement group in its pattern, skipping")
+			continue
+		}
+		if pattern.SubexpIndex("game") < 0 && config.GameID == "" {
+			fmt.Println("Log source", config.Name, "has neither a game group nor a game ID, skipping")
+			continue
+		}
+		sources = append(sources, &logSource{
+			LogSource: config,
+			pattern:   pattern,
+			tailer:    logtail.New(config.Path),
+		})
+	}
+	return sources
+}
+
+// match reads an unlock out of a log line, if the line announces one.
+func (s *logSource) match(line string) (logUnlock, bool) {
+	m := s.pattern.FindStringSubmatch(line)
+	if m == nil {
+		return logUnlock{}, false
+	}
+	group := func(name string) string {
+		if i := s.pattern.SubexpIndex(name); i >= 0 {
+			return strings.TrimSpace(m[i])
+		}
+		return ""
+	}
+
+	u := logUnlock{gameId: group("game"), achievement: group("achievement"), time: time.Now()}
+	if u.gameId == "" {
+		u.gameId = s.GameID
+	}
+	if u.achievement == "" || u.gameId == "" {
+		return logUnlock{}, false
+	}
+	if value := group("time"); value != "" {
+		if t, err := s.parseTime(value); err == nil {
+			u.time = t
+		} else {
+			fmt.Println("Could not parse time in log source", s.Name+":", err)
+		}
+	}
+	return u, true
+}
+
+func (s *logSource) parseTime(value string) (time.Time, error) {
+	if s.TimeLayout != "" {
+		return time.ParseInLocation(s.TimeLayout, value, time.Local)
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(n, 0), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// gameKeyId turns a game ID read from the log into the ID its state is
+// tracked under. Only Steam app IDs are used as they are; the others are
+// prefixed with their platform, like the IDs of other non-Steam emulators,
+// so numeric RetroAchievements or Xenia IDs aren't looked up on Steam.
+func (s *logSource) gameKeyId(gameId string) string {
+	platform := strings.ToLower(s.Platform)
+	if platform == "steam" {
+		return gameId
+	}
+	if platform == "" {
+		platform = "log"
+	}
+	return platform + "-" + gameId
+}
+
+// poll reads whatever the log gained since the last poll and feeds every
+// unlock it announces into the same handling as achievement files.
+func (s *logSource) poll() {
+	lines, err := s.tailer.ReadLines()
+	if err != nil {
+		fmt.Println("Error reading log source", s.Name+":", err)
+		return
+	}
+	for _, line := range lines {
+		u, ok := s.match(line)
+		if !ok {
+			continue
+		}
+		fmt.Println("Log source", s.Name, "reported unlock:", u.gameId, u.achievement)
+
+		// Logs name no emulator profile, so NotifyProfiles, which picks
+		// between profiles, doesn't apply to them.
+		key := gameKey{AppID: s.gameKeyId(u.gameId)}
+		updateAchievements(key, s.Name, func(old map[string]parser.Achievement) map[string]parser.Achievement {
+			achievements := make(map[string]parser.Achievement, len(old)+1)
+			maps.Copy(achievements, old)
+			if a, ok := old[u.achievement]; !ok || !a.Achieved {
+				achievements[u.achievement] = parser.Achievement{Name: u.achievement, Achieved: true, UnlockTime: u.time}
+			}
+			return achievements
+		}, true)
+	}
+}
+
+// tailLogSources polls the log sources until stop is closed.
+func tailLogSources(sources []*logSource, stop <-chan any) {
+	if len(sources) == 0 {
+		return
+	}
+	for _, s := range sources {
+		s.poll()
+	}
+	ticker := time.NewTicker(logPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			for _, s := range sources {
+				s.poll()
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package watcherservice
+
+import (
+	"Achievement-Thing/internal/parser"
+	"Achievement-Thing/internal/settingservice"
+	"fmt"
+	"maps"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLogSourceMatch(t *testing.T) {
+	sources := newLogSources([]settingservice.LogSource{
+		{Name: "ra", Pattern: `game=(?P<game>\d+) unlocked (?P<achievement>\w+) at (?P<time>\d+)`, Platform: "RetroAchievements"},
+		{Name: "fixed", Pattern: `Unlocked (?P<achievement>\w+)`, GameID: "480", Platform: "steam"},
+		{Name: "no achievement group", Pattern: `Unlocked \w+`, GameID: "480"},
+		{Name: "no game", Pattern: `Unlocked (?P<achievement>\w+)`},
+		{Name: "invalid", Pattern: `(`},
+	})
+	if len(sources) != 2 {
+		t.Fatalf("got %d sources, want 2", len(sources))
+	}
+	ra, fixed := sources[0], sources[1]
+
+	u, ok := ra.match("game=1446 unlocked WIN at 1700000000")
+	if !ok || u.gameId != "1446" || u.achievement != "WIN" || !u.time.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("match = %+v, %v", u, ok)
+	}
+	if _, ok := ra.match("game=1446 loaded"); ok {
+		t.Error("a line without an unlock should not match")
+	}
+	if u, ok := fixed.match("Unlocked ACH_WIN"); !ok || u.gameId != "480" || u.achievement != "ACH_WIN" {
+		t.Errorf("match with a fixed game ID = %+v, %v", u, ok)
+	}
+}
+
+func TestLogSourceGameKeyId(t *testing.T) {
+	tests := []struct {
+		platform string
+		want     string
+	}{
+		{"steam", "480"},
+		{"Steam", "480"},
+		{"RetroAchievements", "retroachievements-480"},
+		{"", "log-480"},
+	}
+	for _, tt := range tests {
+		s := &logSource{LogSource: settingservice.LogSource{Platform: tt.platform}}
+		if got := s.gameKeyId("480"); got != tt.want {
+			t.Errorf("gameKeyId with platform %q = %q, want %q", tt.platform, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateAchievementsKeepsConcurrentUpdates(t *testing.T) {
+	key := gameKey{AppID: "log-merge-test"}
+	captureEvents(t, func(Event) {})
+	t.Cleanup(func() {
+		currentAchievementsMutex.Lock()
+		delete(currentAchievements, key)
+		delete(currentSources, key)
+		currentAchievementsMutex.Unlock()
+	})
+
+	// Each update adds one unlock to whatever is tracked, the way log
+	// sources do; none may be lost to another running at the same time.
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name := fmt.Sprintf("ACH_%d", i)
+			updateAchievements(key, "test", func(old map[string]parser.Achievement) map[string]parser.Achievement {
+				achievements := maps.Clone(old)
+				if achievements == nil {
+					achievements = make(map[string]parser.Achievement)
+				}
+				achievements[name] = parser.Achievement{Name: name, Achieved: true}
+				return achievements
+			}, false)
+		}()
+	}
+	wg.Wait()
+
+	currentAchievementsMutex.Lock()
+	got := len(currentAchievements[key])
+	currentAchievementsMutex.Unlock()
+	if got != 50 {
+		t.Errorf("tracked %d achievements, want 50", got)
+	}
+	if unlocks := len(History()); unlocks != 50 {
+		t.Errorf("emitted %d unlocks, want 50", unlocks)
+	}
+}
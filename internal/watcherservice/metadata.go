@@ -0,0 +1,254 @@
+package watcherservice
+
+import (
+	"Achievement-Thing/internal/helper"
+	"Achievement-Thing/internal/notifier"
+	"Achievement-Thing/internal/settingservice"
+	"Achievement-Thing/internal/steam"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// The Steam lookups event metadata comes from. Tests swap them out so that
+// nothing is fetched or cached.
+var (
+	lookupAchievement = steam.GetAchievement
+	lookupRarity      = steam.GetAchievementRarity
+	lookupGameName    = steam.GetGameName
+)
+
+// describe fills in an event's display metadata, from the achievement file
+// itself for emulators that carry it and from the Steam schema otherwise.
+// Without either, the event is marked degraded and gets a name made from the
+// raw API name instead.
+func describe(event *Event) {
+	if info := event.Achievement.Info; info != nil {
+		event.DisplayName = info.DisplayName
+		event.Description = info.Description
+		event.Icon = getIcon(event.AppID, &steam.Achievement{Icon: info.Icon})
+		event.Grade = info.Grade
+		return
+	}
+
+	isSteamGame := helper.IsSteamAppId(event.AppID)
+	if isSteamGame {
+		achievementInfo, err := lookupAchievement(currentContext(), event.AppID, event.Achievement.Name, currentApiKey())
+		if err == nil {
+			event.DisplayName = achievementInfo.DisplayName
+			event.Description = achievementInfo.Description
+			event.Icon = getIcon(event.AppID, achievementInfo)
+			event.Degraded = false
+			describeRarity(event)
+			return
+		}
+		fmt.Println("Error fetching achievement info, using fallback:", err)
+	}
+
+	event.DisplayName = prettifyApiName(event.Achievement.Name)
+	event.Description = event.Achievement.Name
+	event.Icon = ""
+	if !isSteamGame {
+		// No schema is coming for other stores, so there's nothing to backfill.
+		return
+	}
+	if gameName := lookupGameName(event.AppID); gameName != "" {
+		event.Description = gameName + " · " + event.Achievement.Name
+	}
+	event.Degraded = true
+	describeRarity(event)
+}
+
+// describeRarity sets an unlock event's global unlock percentage, as far as
+// it is cached. The notification doesn't wait for it to be fetched.
+func describeRarity(event *Event) {
+	if event.Kind != EventUnlocked {
+		return
+	}
+	if percent, ok := lookupRarity(currentContext(), event.AppID, event.Achievement.Name); ok {
+		event.Rarity = &percent
+		if tier, ok := rarityTier(percent); ok {
+			event.Tier = tier.Name
+		}
+	}
+}
+
+// rarityTier returns the configured tier an unlock percentage falls in.
+func rarityTier(percent float64) (settingservice.RarityTier, bool) {
+	for _, tier := range rarityTiers {
+		if percent <= tier.MaxPercent {
+			return tier, true
+		}
+	}
+	return settingservice.RarityTier{}, false
+}
+
+// notificationStyle picks the sound, attribution and icon frame of an
+// unlock notification from the rarity tier of the achievement.
+func notificationStyle(event Event) notifier.Style {
+	if event.Rarity == nil {
+		return notifier.Style{}
+	}
+	tier, ok := rarityTier(*event.Rarity)
+	if !ok {
+		return notifier.Style{}
+	}
+	return notifier.Style{Sound: tier.Sound, Attribution: tier.Name, FrameColor: tier.FrameColor}
+}
+
+// notificationMessage is the body text of an unlock notification.
+func notificationMessage(event Event) string {
+	message := event.Description
+	if event.Grade != "" {
+		message = event.Grade + " trophy · " + message
+	}
+	if event.Rarity != nil {
+		message += fmt.Sprintf(" · Unlocked by %.1f%% of players", *event.Rarity)
+	}
+	if event.Source != "" {
+		message += " (via " + event.Source + ")"
+	}
+	return message
+}
+
+func init() {
+	steam.OnSchemaCached(func(appId string) {
+		if hasDegradedHistory(appId) {
+			backfillHistory(appId)
+		}
+	})
+}
+
+// backfilledInfo is the metadata looked up for a degraded event.
+type backfilledInfo struct {
+	displayName string
+	description string
+	icon        string
+}
+
+// backfillHistory fills in the metadata of earlier degraded events for an
+// app once its schema has become available. The lookups, which may hit the
+// network, are done without holding the history lock.
+func backfillHistory(appId string) {
+	eventsMutex.RLock()
+	var names []string
+	for _, e := range history {
+		if e.Degraded && e.AppID == appId && !slices.Contains(names, e.Achievement.Name) {
+			names = append(names, e.Achievement.Name)
+		}
+	}
+	eventsMutex.RUnlock()
+
+	infos := make(map[string]backfilledInfo)
+	for _, name := range names {
+		achievementInfo, err := lookupAchievement(currentContext(), appId, name, currentApiKey())
+		if err != nil {
+			continue
+		}
+		infos[name] = backfilledInfo{
+			displayName: achievementInfo.DisplayName,
+			description: achievementInfo.Description,
+			icon:        getIcon(appId, achievementInfo),
+		}
+	}
+	if len(infos) == 0 {
+		return
+	}
+
+	eventsMutex.Lock()
+	updated := false
+	for i := range history {
+		info, ok := infos[history[i].Achievement.Name]
+		if !ok || !history[i].Degraded || history[i].AppID != appId {
+			continue
+		}
+		history[i].DisplayName = info.displayName
+		history[i].Description = info.description
+		history[i].Icon = info.icon
+		history[i].Degraded = false
+		updated = true
+	}
+	eventsMutex.Unlock()
+
+	if updated {
+		fmt.Println("Filled in achievement metadata for appId:", appId)
+		emit(Event{Kind: EventMetadata, AppID: appId})
+	}
+}
+
+// backfillAllHistory fills in the metadata of every app with degraded
+// events, for when a new API key or schema source may have made it
+// available.
+func backfillAllHistory() {
+	eventsMutex.RLock()
+	var appIds []string
+	for _, e := range history {
+		if e.Degraded && !slices.Contains(appIds, e.AppID) {
+			appIds = append(appIds, e.AppID)
+		}
+	}
+	eventsMutex.RUnlock()
+
+	for _, appId := range appIds {
+		backfillHistory(appId)
+	}
+}
+
+// hasDegradedHistory reports whether any recorded event for an app is still
+// missing its metadata.
+func hasDegradedHistory(appId string) bool {
+	eventsMutex.RLock()
+	defer eventsMutex.RUnlock()
+	for _, e := range history {
+		if e.Degraded && e.AppID == appId {
+			return true
+		}
+	}
+	return false
+}
+
+var apiNamePrefixes = []string{"NEW_ACHIEVEMENT_", "ACHIEVEMENT_", "ACH_"}
+
+// prettifyApiName turns an API name such as ACH_WIN_100_GAMES or
+// winHundredGames into something readable: "Win 100 Games".
+func prettifyApiName(name string) string {
+	trimmed := name
+	for _, prefix := range apiNamePrefixes {
+		if len(trimmed) > len(prefix) && strings.EqualFold(trimmed[:len(prefix)], prefix) {
+			trimmed = trimmed[len(prefix):]
+			break
+		}
+	}
+
+	var words []string
+	var word []rune
+	runes := []rune(trimmed)
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-' || r == '.' || unicode.IsSpace(r):
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		case i > 0 && unicode.IsUpper(r) && unicode.IsLower(runes[i-1]) && len(word) > 0:
+			words = append(words, string(word))
+			word = nil
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	if len(words) == 0 {
+		return name
+	}
+
+	for i, w := range words {
+		r := []rune(strings.ToLower(w))
+		r[0] = unicode.ToUpper(r[0])
+		words[i] = string(r)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,225 @@
+package watcherservice
+
+import (
+	"Achievement-Thing/internal/notifier"
+	"Achievement-Thing/internal/parser"
+	"Achievement-Thing/internal/settingservice"
+	"Achievement-Thing/internal/steam"
+	"context"
+	"errors"
+	"testing"
+)
+
+// useSteamLookups answers metadata lookups from schemas and rarity, both
+// keyed by app ID and then API name, for the duration of a test.
+// It returns the number of achievement lookups made.
+func useSteamLookups(t *testing.T, schemas map[string]map[string]steam.Achievement, rarity map[string]map[string]float64) *int {
+	t.Helper()
+	calls := new(int)
+	savedAchievement, savedRarity, savedGameName := lookupAchievement, lookupRarity, lookupGameName
+	lookupAchievement = func(ctx context.Context, appid string, name string, apikey string) (*steam.Achievement, error) {
+		*calls++
+		if a, ok := schemas[appid][name]; ok {
+			return &a, nil
+		}
+		return nil, errors.New("achievement not found")
+	}
+	lookupRarity = func(ctx context.Context, appid string, name string) (float64, bool) {
+		percent, ok := rarity[appid][name]
+		return percent, ok
+	}
+	lookupGameName = func(appid string) string {
+		if appid == "480" {
+			return "Spacewar"
+		}
+		return ""
+	}
+	t.Cleanup(func() {
+		lookupAchievement, lookupRarity, lookupGameName = savedAchievement, savedRarity, savedGameName
+	})
+	return calls
+}
+
+func useRarityTiers(t *testing.T) {
+	t.Helper()
+	saved := rarityTiers
+	rarityTiers = []settingservice.RarityTier{
+		{Name: "Ultra rare", MaxPercent: 5, Sound: "ultra.wav", FrameColor: "#ffd700"},
+		{Name: "Rare", MaxPercent: 20, Sound: "rare.wav"},
+	}
+	t.Cleanup(func() { rarityTiers = saved })
+}
+
+func TestDescribe(t *testing.T) {
+	useSteamLookups(t, map[string]map[string]steam.Achievement{
+		"480": {"ACH_WIN": {ApiName: "ACH_WIN", DisplayName: "Winner", Description: "Win a match"}},
+	}, map[string]map[string]float64{"480": {"ACH_WIN": 3.5, "ACH_TRAVEL_FAR": 50}})
+	useRarityTiers(t)
+
+	tests := []struct {
+		name  string
+		event Event
+		want  Event
+	}{
+		{
+			name:  "from the Steam schema",
+			event: Event{Kind: EventUnlocked, AppID: "480", Achievement: parser.Achievement{Name: "ACH_WIN"}},
+			want:  Event{DisplayName: "Winner", Description: "Win a match", Tier: "Ultra rare"},
+		},
+		{
+			name:  "progress gets no rarity",
+			event: Event{Kind: EventProgress, AppID: "480", Achievement: parser.Achievement{Name: "ACH_WIN"}},
+			want:  Event{DisplayName: "Winner", Description: "Win a match"},
+		},
+		{
+			name:  "missing from the schema",
+			event: Event{Kind: EventUnlocked, AppID: "480", Achievement: parser.Achievement{Name: "ACH_TRAVEL_FAR"}},
+			want:  Event{DisplayName: "Travel Far", Description: "Spacewar · ACH_TRAVEL_FAR", Degraded: true},
+		},
+		{
+			name:  "unknown game",
+			event: Event{Kind: EventUnlocked, AppID: "570", Achievement: parser.Achievement{Name: "ACH_WIN"}},
+			want:  Event{DisplayName: "Win", Description: "ACH_WIN", Degraded: true},
+		},
+		{
+			name:  "not a Steam game",
+			event: Event{Kind: EventUnlocked, AppID: "NPWR00001_00", Achievement: parser.Achievement{Name: "firstBlood"}},
+			want:  Event{DisplayName: "First Blood", Description: "firstBlood"},
+		},
+		{
+			name: "metadata from the file",
+			event: Event{Kind: EventUnlocked, AppID: "NPWR00001_00", Achievement: parser.Achievement{
+				Name: "001", Info: &parser.Info{DisplayName: "First", Description: "Do something", Grade: "Bronze"},
+			}},
+			want: Event{DisplayName: "First", Description: "Do something", Grade: "Bronze"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := tt.event
+			describe(&event)
+			if event.DisplayName != tt.want.DisplayName || event.Description != tt.want.Description ||
+				event.Grade != tt.want.Grade || event.Tier != tt.want.Tier || event.Degraded != tt.want.Degraded {
+				t.Errorf("got %+v\nwant %+v", event, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrettifyApiName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"ACH_WIN_100_GAMES", "Win 100 Games"},
+		{"NEW_ACHIEVEMENT_1_4", "1 4"},
+		{"achievement_first-blood", "First Blood"},
+		{"winHundredGames", "Win Hundred Games"},
+		{"HTMLParser", "Htmlparser"},
+		{"reach.level 10", "Reach Level 10"},
+		{"ACH_", "Ach"},
+		{"___", "___"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := prettifyApiName(tt.name); got != tt.want {
+			t.Errorf("prettifyApiName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBackfillHistory(t *testing.T) {
+	calls := useSteamLookups(t, map[string]map[string]steam.Achievement{
+		"480": {"ACH_WIN": {ApiName: "ACH_WIN", DisplayName: "Winner", Description: "Win a match"}},
+		"570": {"ACH_WIN": {ApiName: "ACH_WIN", DisplayName: "Other winner"}},
+	}, nil)
+	var emitted []Event
+	captureEvents(t, func(ev Event) { emitted = append(emitted, ev) })
+
+	degraded := func(appId, name string) Event {
+		return Event{Kind: EventUnlocked, AppID: appId, Achievement: parser.Achievement{Name: name},
+			DisplayName: prettifyApiName(name), Description: name, Degraded: true}
+	}
+	eventsMutex.Lock()
+	history = []Event{
+		degraded("480", "ACH_WIN"),
+		degraded("480", "ACH_LOSE"),
+		degraded("570", "ACH_WIN"),
+		degraded("480", "ACH_WIN"),
+	}
+	eventsMutex.Unlock()
+
+	backfillHistory("480")
+
+	got := History()
+	for _, i := range []int{0, 3} {
+		if e := got[i]; e.Degraded || e.DisplayName != "Winner" || e.Description != "Win a match" {
+			t.Errorf("event %d was not filled in: %+v", i, e)
+		}
+	}
+	if !got[1].Degraded || got[1].DisplayName != "Lose" {
+		t.Errorf("an achievement missing from the schema was changed: %+v", got[1])
+	}
+	if !got[2].Degraded || got[2].DisplayName != "Win" {
+		t.Errorf("another app's event was changed: %+v", got[2])
+	}
+	// Each name is looked up once, however many events share it.
+	if *calls != 2 {
+		t.Errorf("made %d lookups, want 2", *calls)
+	}
+	if len(emitted) != 1 || emitted[0].Kind != EventMetadata || emitted[0].AppID != "480" {
+		t.Errorf("emitted %+v, want one metadata event for 480", emitted)
+	}
+
+	// Nothing left to fill in for the app means nothing is emitted.
+	emitted = nil
+	backfillHistory("480")
+	if len(emitted) != 0 {
+		t.Errorf("emitted %+v with nothing new to fill in", emitted)
+	}
+}
+
+func TestRarityTier(t *testing.T) {
+	useRarityTiers(t)
+
+	tests := []struct {
+		percent float64
+		want    string
+		ok      bool
+	}{
+		{0.1, "Ultra rare", true},
+		{5, "Ultra rare", true},
+		{5.01, "Rare", true},
+		{20, "Rare", true},
+		{20.5, "", false},
+		{100, "", false},
+	}
+	for _, tt := range tests {
+		tier, ok := rarityTier(tt.percent)
+		if ok != tt.ok || tier.Name != tt.want {
+			t.Errorf("rarityTier(%v) = %q, %v; want %q, %v", tt.percent, tier.Name, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNotificationStyle(t *testing.T) {
+	useRarityTiers(t)
+	percent := func(p float64) *float64 { return &p }
+
+	tests := []struct {
+		name   string
+		rarity *float64
+		want   notifier.Style
+	}{
+		{"unknown rarity", nil, notifier.Style{}},
+		{"ultra rare", percent(3.5), notifier.Style{Sound: "ultra.wav", Attribution: "Ultra rare", FrameColor: "#ffd700"}},
+		{"rare", percent(12), notifier.Style{Sound: "rare.wav", Attribution: "Rare"}},
+		{"common", percent(60), notifier.Style{}},
+	}
+	for _, tt := range tests {
+		if got := notificationStyle(Event{Rarity: tt.rarity}); got != tt.want {
+			t.Errorf("%s: style = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
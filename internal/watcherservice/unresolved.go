@@ -0,0 +1,59 @@
+package watcherservice
+
+import (
+	"Achievement-Thing/internal/parser"
+	"slices"
+	"sync"
+	"time"
+)
+
+// UnresolvedFile is an achievement file whose app ID couldn't be worked out,
+// waiting for the user to add an override for it.
+type UnresolvedFile struct {
+	Path   string    `json:"path"`
+	Source string    `json:"source,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+const maxUnresolvedFiles = 100
+
+var unresolvedFiles []UnresolvedFile
+var unresolvedFilesMutex sync.RWMutex
+
+// queueUnresolved records a file whose app ID couldn't be worked out. A
+// file that is already queued only has its time updated.
+func queueUnresolved(path string) {
+	unresolvedFilesMutex.Lock()
+	i := slices.IndexFunc(unresolvedFiles, func(f UnresolvedFile) bool { return f.Path == path })
+	if i >= 0 {
+		unresolvedFiles[i].Time = time.Now()
+		unresolvedFilesMutex.Unlock()
+		return
+	}
+	unresolvedFiles = append(unresolvedFiles, UnresolvedFile{
+		Path:   path,
+		Source: parser.IdentifySource(path, "", ""),
+		Time:   time.Now(),
+	})
+	if len(unresolvedFiles) > maxUnresolvedFiles {
+		unresolvedFiles = unresolvedFiles[len(unresolvedFiles)-maxUnresolvedFiles:]
+	}
+	unresolvedFilesMutex.Unlock()
+
+	emit(Event{Kind: EventUnresolved, Path: path})
+}
+
+// dequeueUnresolved drops a file from the queue once its app ID is known.
+func dequeueUnresolved(path string) {
+	unresolvedFilesMutex.Lock()
+	defer unresolvedFilesMutex.Unlock()
+	unresolvedFiles = slices.DeleteFunc(unresolvedFiles, func(f UnresolvedFile) bool { return f.Path == path })
+}
+
+// UnresolvedFiles returns the achievement files whose app ID couldn't be
+// worked out, oldest first.
+func UnresolvedFiles() []UnresolvedFile {
+	unresolvedFilesMutex.RLock()
+	defer unresolvedFilesMutex.RUnlock()
+	return append([]UnresolvedFile(nil), unresolvedFiles...)
+}
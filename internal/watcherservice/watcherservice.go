@@ -7,93 +7,318 @@ import (
 	"Achievement-Thing/internal/settingservice"
 	"Achievement-Thing/internal/steam"
 	"Achievement-Thing/pkg/filewatcher"
+	"cmp"
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
 )
 
-var achievementFiles = []string{
-	"achievements.ini",
-	"achievements.json",
-	"achiev.ini",
-	"Achievements.ini",
-}
-
 var watcher *filewatcher.FileWatcher
 var stopChan chan any
-var currentAchievements = make(map[string]map[string]parser.Achievement)
+
+// watcherCtx is cancelled when the watcher stops, abandoning Steam requests
+// that are still in flight.
+var watcherCtx, cancelWatcherCtx = context.WithCancel(context.Background())
+var watcherCtxMutex sync.RWMutex
+
+func currentContext() context.Context {
+	watcherCtxMutex.RLock()
+	defer watcherCtxMutex.RUnlock()
+	return watcherCtx
+}
+
+// gameKey identifies a game's tracked state. Profile is empty for files that
+// aren't stored per user.
+type gameKey struct {
+	Profile string
+	AppID   string
+}
+
+// currentAchievementsMutex guards both currentAchievements and
+// currentSources, which are always updated together.
+var currentAchievements = make(map[gameKey]map[string]parser.Achievement)
+var currentAchievementsMutex sync.Mutex
+var currentSources = make(map[gameKey]string)
+var currentStats = make(map[gameKey]map[string]parser.Stat)
+var currentStatsMutex sync.RWMutex
 
 var folders []string
 var apiKey string
+var apiKeyMutex sync.RWMutex
+var progressThresholds []int
+var lenientParsing bool
+var notifyProfiles []string
+var logSources []*logSource
+var rarityTiers []settingservice.RarityTier
 
 const maxNotifyAchievements = 2
 
 func FileEventHandler(event filewatcher.EventType, path string) {
 	fmt.Println("File event:", event, path)
-	if path == "" {
+	if path == "" || !parser.MatchFile(path) {
 		return
 	}
-	if apiKey == "" {
-		fmt.Println("No API Key set, cannot fetch achievement info")
-		return
+	if currentApiKey() == "" {
+		fmt.Println("No API Key set, using local achievement schemas only")
 	}
 	appId := helper.ExtractAppId(path)
 	if appId == "" {
 		fmt.Println("Could not extract appId from path:", path)
+		queueUnresolved(path)
 		return
 	}
+	dequeueUnresolved(path)
+	key := gameKey{Profile: helper.ExtractProfile(path), AppID: appId}
+	notify := shouldNotifyProfile(key.Profile)
+	isSteamGame := helper.IsSteamAppId(appId)
+	if isSteamGame {
+		steam.RememberSteamSettings(appId, path)
+	}
 
-	if event == filewatcher.FileCreated {
-		err := steam.CacheAchievements(apiKey, appId)
+	if event == filewatcher.FileCreated && isSteamGame {
+		err := steam.CacheAchievements(currentContext(), currentApiKey(), appId)
 		if err != nil {
 			fmt.Println("Error caching achievements:", err)
 		}
 	}
 
-	f, err := os.Open(path)
+	result, err := parseFile(path, appId)
 	if err != nil {
-		fmt.Println("Error opening file:", err)
-		return
-	}
-	defer f.Close()
-	achievements, err := parser.ParseFile(f, path)
-	if err != nil {
-		fmt.Println("Error parsing file:", err)
+		fmt.Println("Error parsing file from", sourceName(parser.IdentifySource(path, "", ""))+":", err)
 		return
 	}
+	fmt.Println("Parsed file as", result.Format, "with dialect", result.Dialect, "from", sourceName(result.Source))
+	updateStats(key, result.Stats, true)
+	handleAchievements(key, result.Source, result.Achievements, notify)
+}
 
-	oldAchievements, exists := currentAchievements[appId]
-	if !exists {
-		oldAchievements = make(map[string]parser.Achievement)
-	}
+// handleAchievements compares a game's freshly read achievements with its
+// tracked state, records them along with the source they were read from, and
+// emits and notifies whatever was unlocked or progressed since.
+func handleAchievements(key gameKey, source string, achievements map[string]parser.Achievement, notify bool) {
+	updateAchievements(key, source, func(map[string]parser.Achievement) map[string]parser.Achievement {
+		return achievements
+	}, notify)
+}
+
+// updateAchievements is handleAchievements for sources that only report
+// part of a game's state: update is given the tracked achievements, which it
+// must not modify, and returns the game's new state.
+func updateAchievements(key gameKey, source string, update func(old map[string]parser.Achievement) map[string]parser.Achievement, notify bool) {
+	appId := key.AppID
+	isSteamGame := helper.IsSteamAppId(appId)
 	newAchievements := make([]parser.Achievement, 0)
+	progressAchievements := make([]parser.Achievement, 0)
+
+	// The comparison and update happen under one lock, so that an unlock
+	// seen by two event handlers at once is only reported once, and a
+	// partial update can't undo a concurrent one.
+	currentAchievementsMutex.Lock()
+	oldAchievements := currentAchievements[key]
+	achievements := update(oldAchievements)
 	for k, v := range achievements {
 		oldAch, ok := oldAchievements[k]
-		if !ok || (ok && !oldAch.Achieved && v.Achieved) {
+		if v.Achieved && (!ok || !oldAch.Achieved) {
 			newAchievements = append(newAchievements, v)
+		} else if ok && crossedProgressThreshold(oldAch, v) {
+			progressAchievements = append(progressAchievements, v)
 		}
 	}
-	if count := len(newAchievements); count > 0 && count <= maxNotifyAchievements {
-		currentAchievements[appId] = achievements
-		fmt.Println("New achievements for appId:", appId)
+	if len(achievements) > 0 {
+		currentAchievements[key] = achievements
+		currentSources[key] = source
+	}
+	currentAchievementsMutex.Unlock()
+
+	sort.Slice(newAchievements, func(i, j int) bool {
+		return newAchievements[i].UnlockTime.Before(newAchievements[j].UnlockTime)
+	})
+	if len(newAchievements) > maxNotifyAchievements {
+		fmt.Println("Too many new achievements to notify for appId:", appId)
+		notify = false
+	}
+
+	if len(newAchievements) > 0 {
+		fmt.Println("New achievements for appId:", appId, "profile:", key.Profile)
 		for _, v := range newAchievements {
 			fmt.Println("  New Achievement: ", v.Name)
-			achievementInfo, err := steam.GetAchievement(appId, v.Name, apiKey)
-			if err == nil {
-				icon := ""
-				if achievementInfo.Icon != "" {
-					iconPath, err := steam.GetImage(appId, achievementInfo.Icon)
-					if err == nil {
-						icon = iconPath
-					} else {
-						fmt.Println("Error fetching achievement icon:", err)
-					}
-				}
-				notifier.SendAchievement(achievementInfo.DisplayName, achievementInfo.Description, icon)
-			} else {
-				fmt.Println("Error fetching achievement info:", err)
+			ev := Event{Kind: EventUnlocked, Profile: key.Profile, AppID: appId, Achievement: v, Time: v.UnlockTime, Source: source}
+			describe(&ev)
+			emit(ev)
+			if notify {
+				notifier.SendAchievement(ev.DisplayName, notificationMessage(ev), ev.Icon, notificationStyle(ev))
+			}
+		}
+	}
+
+	for _, v := range progressAchievements {
+		fmt.Println("  Achievement progress: ", v.Name, v.CurProgress, "/", v.MaxProgress)
+		ev := Event{Kind: EventProgress, Profile: key.Profile, AppID: appId, Achievement: v, Source: source}
+		describe(&ev)
+		emit(ev)
+		if notify {
+			notifier.SendProgress(ev.DisplayName, v.CurProgress, v.MaxProgress, ev.Icon)
+		}
+	}
+
+	if isSteamGame && hasDegradedHistory(appId) {
+		backfillHistory(appId)
+	}
+}
+
+// updateStats merges newly read stat values into the tracked state, emitting
+// an event for every value that changed when notify is set.
+func updateStats(key gameKey, stats map[string]parser.Stat, notify bool) {
+	if len(stats) == 0 {
+		return
+	}
+	var events []Event
+	currentStatsMutex.Lock()
+	old, exists := currentStats[key]
+	if !exists {
+		old = make(map[string]parser.Stat)
+		currentStats[key] = old
+	}
+	for name, stat := range stats {
+		if prev, ok := old[name]; notify && (!ok || prev.Value != stat.Value) {
+			fmt.Println("  Stat changed: ", name, stat.Value)
+			events = append(events, Event{Kind: EventStat, Profile: key.Profile, AppID: key.AppID, Stat: stat})
+		}
+		old[name] = stat
+	}
+	currentStatsMutex.Unlock()
+
+	// Emitted after unlocking, so handlers can call Stats.
+	for _, ev := range events {
+		emit(ev)
+	}
+}
+
+// Source returns the emulator or tool an app's achievements for a profile
+// were last read from, or "" if it isn't known.
+func Source(profile string, appId string) string {
+	currentAchievementsMutex.Lock()
+	defer currentAchievementsMutex.Unlock()
+	return currentSources[gameKey{Profile: profile, AppID: appId}]
+}
+
+// sourceName is a source for log messages.
+func sourceName(source string) string {
+	if source == "" {
+		return "unknown source"
+	}
+	return source
+}
+
+// Stats returns the last known stat values for an app and profile.
+func Stats(profile string, appId string) map[string]parser.Stat {
+	currentStatsMutex.RLock()
+	defer currentStatsMutex.RUnlock()
+	stats := make(map[string]parser.Stat)
+	for name, stat := range currentStats[gameKey{Profile: profile, AppID: appId}] {
+		stats[name] = stat
+	}
+	return stats
+}
+
+// shouldNotifyProfile reports whether unlocks for a profile should raise
+// notifications. With no profiles configured, every profile notifies.
+func shouldNotifyProfile(profile string) bool {
+	return len(notifyProfiles) == 0 || slices.Contains(notifyProfiles, profile)
+}
+
+func parseFile(path string, appId string) (*parser.Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	opts := parser.Options{
+		Lenient: lenientParsing,
+		SchemaNames: func() []string {
+			names, err := steam.AchievementNames(currentContext(), currentApiKey(), appId)
+			if err != nil {
+				fmt.Println("Error loading achievement names:", err)
 			}
+			return names
+		},
+		StatTypes: func() map[string]string {
+			return steam.StatTypes(appId)
+		},
+	}
+	result, err := parser.ParseWithOptions(f, path, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range result.Warnings {
+		fmt.Println("Warning parsing", path+":", w)
+	}
+	return result, nil
+}
+
+func getIcon(appId string, achievementInfo *steam.Achievement) string {
+	if achievementInfo.Icon == "" {
+		return ""
+	}
+	iconPath, err := steam.GetImage(currentContext(), appId, achievementInfo.Icon)
+	if err != nil {
+		fmt.Println("Error fetching achievement icon:", err)
+		return ""
+	}
+	return iconPath
+}
+
+// crossedProgressThreshold reports whether a still-locked achievement moved
+// past one of the configured progress thresholds since it was last seen.
+func crossedProgressThreshold(old, current parser.Achievement) bool {
+	if current.Achieved || current.MaxProgress <= 0 || current.CurProgress <= old.CurProgress {
+		return false
+	}
+	oldPercent := old.CurProgress * 100 / current.MaxProgress
+	newPercent := current.CurProgress * 100 / current.MaxProgress
+	for _, threshold := range progressThresholds {
+		if oldPercent < threshold && newPercent >= threshold {
+			return true
 		}
 	}
+	return false
+}
+
+// applyMetadataSettings applies the settings that decide where achievement
+// metadata and app IDs come from.
+func applyMetadataSettings(settings settingservice.Settings) {
+	apiKeyMutex.Lock()
+	apiKey = settings.ApiKey
+	apiKeyMutex.Unlock()
+	helper.SetAppIdOverrides(settings.AppIdOverrides)
+	steam.SetSteamPath(settings.SteamPath)
+	steam.SetLanguage(settings.Language)
+	steam.SetGameFolders(settings.GameFolders)
+}
+
+func currentApiKey() string {
+	apiKeyMutex.RLock()
+	defer apiKeyMutex.RUnlock()
+	return apiKey
+}
+
+// ReloadSettings applies saved changes to the API key, Steam path, language,
+// game folders and app ID overrides, and fills in the metadata of earlier
+// events that had to do without. Other settings take effect on the next
+// start.
+func ReloadSettings() error {
+	settings, err := settingservice.LoadSettings()
+	if err != nil {
+		return err
+	}
+	applyMetadataSettings(settings)
+	go backfillAllHistory()
+	return nil
 }
 
 func initializeWatcher() error {
@@ -104,14 +329,25 @@ func initializeWatcher() error {
 	}
 
 	folders = settings.Folders
-	apiKey = settings.ApiKey
+	if settings.Rpcs3Path != "" {
+		folders = append(folders, filepath.Join(settings.Rpcs3Path, "dev_hdd0", "home"))
+	}
+	applyMetadataSettings(settings)
+	progressThresholds = settings.ProgressThresholds
+	lenientParsing = settings.LenientParsing
+	notifyProfiles = settings.NotifyProfiles
+	rarityTiers = slices.Clone(settings.RarityTiers)
+	slices.SortFunc(rarityTiers, func(a, b settingservice.RarityTier) int {
+		return cmp.Compare(a.MaxPercent, b.MaxPercent)
+	})
+	logSources = newLogSources(settings.LogSources)
 
 	for _, folder := range folders {
 		if _, err := os.Stat(folder); os.IsNotExist(err) {
 			fmt.Println("Folder does not exist, skipping:", folder)
 			continue
 		}
-		files, err := helper.FindFilesRecursive(folder, achievementFiles)
+		files, err := helper.FindFilesFunc(folder, parser.MatchFile)
 		if err != nil {
 			fmt.Println("Error finding files:", err)
 			return err
@@ -119,28 +355,32 @@ func initializeWatcher() error {
 		for _, file := range files {
 			appId := helper.ExtractAppId(file)
 			if appId != "" {
-				f, openErr := os.Open(file)
-				if openErr != nil {
-					fmt.Println("Error opening file:", openErr)
-					continue
+				key := gameKey{Profile: helper.ExtractProfile(file), AppID: appId}
+				if helper.IsSteamAppId(appId) {
+					steam.RememberSteamSettings(appId, file)
+					go steam.CacheAchievements(currentContext(), currentApiKey(), appId)
 				}
-				defer f.Close()
-				achievements, err := parser.ParseFile(f, file)
+				result, err := parseFile(file, appId)
 				if err == nil {
-					currentAchievements[appId] = achievements
+					achievements := result.Achievements
+					updateStats(key, result.Stats, false)
 
 					if len(achievements) > 0 {
-						fmt.Println("Loaded achievements for appId:", appId)
+						currentAchievementsMutex.Lock()
+						currentAchievements[key] = achievements
+						currentSources[key] = result.Source
+						currentAchievementsMutex.Unlock()
+						fmt.Println("Loaded achievements for appId:", appId, "profile:", key.Profile)
 						for k := range achievements {
 							fmt.Println("  [", k, "]")
 						}
 					}
 				} else {
-					fmt.Println("Error parsing file:", err)
-				}
-				if apiKey != "" {
-					go steam.CacheAchievements(apiKey, appId)
+					fmt.Println("Error parsing file from", sourceName(parser.IdentifySource(file, "", ""))+":", err)
 				}
+			} else {
+				fmt.Println("Could not extract appId from path:", file)
+				queueUnresolved(file)
 			}
 		}
 	}
@@ -149,6 +389,11 @@ func initializeWatcher() error {
 
 func StartWatcher() error {
 	fmt.Println("Starting file watcher...")
+	watcherCtxMutex.Lock()
+	if watcherCtx.Err() != nil {
+		watcherCtx, cancelWatcherCtx = context.WithCancel(context.Background())
+	}
+	watcherCtxMutex.Unlock()
 	if err := initializeWatcher(); err != nil {
 		fmt.Println("Error initializing watcher:", err)
 		return err
@@ -175,6 +420,7 @@ func StartWatcher() error {
 	watcher.Start()
 
 	stopChan = make(chan any)
+	go tailLogSources(logSources, stopChan)
 	go func() {
 		<-stopChan
 		watcher.Close()
@@ -184,6 +430,9 @@ func StartWatcher() error {
 }
 
 func StopWatcher() {
+	watcherCtxMutex.Lock()
+	cancelWatcherCtx()
+	watcherCtxMutex.Unlock()
 	if stopChan != nil {
 		close(stopChan)
 	}
@@ -0,0 +1,103 @@
+package watcherservice
+
+import (
+	"Achievement-Thing/internal/parser"
+	"testing"
+	"time"
+)
+
+// captureEvents replaces the event handlers and history for the duration of
+// a test.
+func captureEvents(t *testing.T, handler func(Event)) {
+	t.Helper()
+	eventsMutex.Lock()
+	savedHandlers, savedHistory := eventHandlers, history
+	eventHandlers, history = []func(Event){handler}, nil
+	eventsMutex.Unlock()
+	t.Cleanup(func() {
+		eventsMutex.Lock()
+		eventHandlers, history = savedHandlers, savedHistory
+		eventsMutex.Unlock()
+	})
+}
+
+func TestUpdateStatsHandlerCanReadStats(t *testing.T) {
+	key := gameKey{Profile: "test", AppID: "480"}
+	seen := make(chan float64, 1)
+	captureEvents(t, func(ev Event) {
+		if ev.Kind == EventStat {
+			seen <- Stats(ev.Profile, ev.AppID)[ev.Stat.Name].Value
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		updateStats(key, map[string]parser.Stat{"kills": {Name: "kills", Value: 3}}, true)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("updateStats deadlocked with a handler that reads Stats")
+	}
+	if value := <-seen; value != 3 {
+		t.Errorf("handler saw kills = %v, want 3", value)
+	}
+}
+
+func TestSourceConcurrentWithUpdates(t *testing.T) {
+	key := gameKey{Profile: "source-test", AppID: "480"}
+	achievements := map[string]parser.Achievement{"ACH_WIN": {Name: "ACH_WIN"}}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			handleAchievements(key, parser.SourceGoldberg, achievements, false)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		Source(key.Profile, key.AppID)
+	}
+	<-done
+
+	if got := Source(key.Profile, key.AppID); got != parser.SourceGoldberg {
+		t.Errorf("Source = %q, want %q", got, parser.SourceGoldberg)
+	}
+}
+
+func TestCrossedProgressThreshold(t *testing.T) {
+	saved := progressThresholds
+	progressThresholds = []int{25, 50, 75}
+	t.Cleanup(func() { progressThresholds = saved })
+
+	progress := func(cur, max int) parser.Achievement {
+		return parser.Achievement{Name: "ACH_GRIND", CurProgress: cur, MaxProgress: max}
+	}
+	tests := []struct {
+		name    string
+		old     parser.Achievement
+		current parser.Achievement
+		want    bool
+	}{
+		{"single crossing", progress(20, 100), progress(30, 100), true},
+		{"landing on a threshold", progress(20, 100), progress(25, 100), true},
+		{"no crossing", progress(30, 100), progress(40, 100), false},
+		{"jump across several", progress(10, 100), progress(90, 100), true},
+		{"progress going down", progress(60, 100), progress(30, 100), false},
+		{"unchanged", progress(30, 100), progress(30, 100), false},
+		{"no maximum", progress(0, 0), progress(5, 0), false},
+		{"already unlocked", progress(20, 100), parser.Achievement{Name: "ACH_GRIND", Achieved: true, CurProgress: 100, MaxProgress: 100}, false},
+		{"small maximum", progress(0, 4), progress(1, 4), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := crossedProgressThreshold(tt.old, tt.current); got != tt.want {
+				t.Errorf("crossedProgressThreshold(%d/%d -> %d/%d) = %v, want %v",
+					tt.old.CurProgress, tt.old.MaxProgress, tt.current.CurProgress, tt.current.MaxProgress, got, tt.want)
+			}
+		})
+	}
+}
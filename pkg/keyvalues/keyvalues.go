@@ -0,0 +1,218 @@
+package keyvalues
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Type is the type tag of a binary KeyValues entry.
+type Type byte
+
+const (
+	TypeNone       Type = 0x00 // a nested section
+	TypeString     Type = 0x01
+	TypeInt32      Type = 0x02
+	TypeFloat32    Type = 0x03
+	TypePointer    Type = 0x04
+	TypeWideString Type = 0x05
+	TypeColor      Type = 0x06
+	TypeUint64     Type = 0x07
+	TypeEnd        Type = 0x08
+	TypeInt64      Type = 0x0a
+	TypeEndAlt     Type = 0x0b
+)
+
+// Node is a KeyValues entry. Sections (TypeNone) have Children; every other
+// type has a Value of the matching Go type: string, int32, float32, uint32
+// (pointers and colors), uint64 or int64.
+type Node struct {
+	Name     string
+	Type     Type
+	Value    any
+	Children []*Node
+}
+
+var ErrTruncated = errors.New("keyvalues: unexpected end of data")
+
+// ParseBinary decodes a binary KeyValues document, as used by Steam's
+// appcache files. The returned root node is an unnamed section holding the
+// document's top-level entries.
+func ParseBinary(data []byte) (*Node, error) {
+	p := &parser{data: data}
+	root := &Node{Type: TypeNone}
+	children, err := p.section(true)
+	if err != nil {
+		return nil, err
+	}
+	root.Children = children
+	return root, nil
+}
+
+type parser struct {
+	data []byte
+	pos  int
+}
+
+// section reads entries up to the end marker. The top level may also end
+// at the end of the data.
+func (p *parser) section(topLevel bool) ([]*Node, error) {
+	var children []*Node
+	for {
+		if p.pos >= len(p.data) {
+			if topLevel {
+				return children, nil
+			}
+			return nil, ErrTruncated
+		}
+
+		t := Type(p.data[p.pos])
+		p.pos++
+		if t == TypeEnd || t == TypeEndAlt {
+			return children, nil
+		}
+
+		name, err := p.cstring()
+		if err != nil {
+			return nil, err
+		}
+		node := &Node{Name: name, Type: t}
+
+		switch t {
+		case TypeNone:
+			node.Children, err = p.section(false)
+		case TypeString:
+			node.Value, err = p.cstring()
+		case TypeWideString:
+			node.Value, err = p.wstring()
+		case TypeInt32:
+			var b []byte
+			if b, err = p.take(4); err == nil {
+				node.Value = int32(binary.LittleEndian.Uint32(b))
+			}
+		case TypeFloat32:
+			var b []byte
+			if b, err = p.take(4); err == nil {
+				node.Value = math.Float32frombits(binary.LittleEndian.Uint32(b))
+			}
+		case TypePointer, TypeColor:
+			var b []byte
+			if b, err = p.take(4); err == nil {
+				node.Value = binary.LittleEndian.Uint32(b)
+			}
+		case TypeUint64:
+			var b []byte
+			if b, err = p.take(8); err == nil {
+				node.Value = binary.LittleEndian.Uint64(b)
+			}
+		case TypeInt64:
+			var b []byte
+			if b, err = p.take(8); err == nil {
+				node.Value = int64(binary.LittleEndian.Uint64(b))
+			}
+		default:
+			return nil, fmt.Errorf("keyvalues: unknown type 0x%02x for key %q at offset %d", byte(t), name, p.pos)
+		}
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, node)
+	}
+}
+
+func (p *parser) take(n int) ([]byte, error) {
+	if p.pos+n > len(p.data) {
+		return nil, ErrTruncated
+	}
+	b := p.data[p.pos : p.pos+n]
+	p.pos += n
+	return b, nil
+}
+
+func (p *parser) cstring() (string, error) {
+	end := bytes.IndexByte(p.data[p.pos:], 0)
+	if end < 0 {
+		return "", ErrTruncated
+	}
+	s := string(p.data[p.pos : p.pos+end])
+	p.pos += end + 1
+	return s, nil
+}
+
+func (p *parser) wstring() (string, error) {
+	var units []uint16
+	for {
+		b, err := p.take(2)
+		if err != nil {
+			return "", err
+		}
+		u := binary.LittleEndian.Uint16(b)
+		if u == 0 {
+			return string(utf16.Decode(units)), nil
+		}
+		units = append(units, u)
+	}
+}
+
+// Child returns the first direct child with the given name, compared
+// case-insensitively, or nil.
+func (n *Node) Child(name string) *Node {
+	if n == nil {
+		return nil
+	}
+	for _, c := range n.Children {
+		if strings.EqualFold(c.Name, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// Path follows a chain of child names, returning nil if any is missing.
+func (n *Node) Path(names ...string) *Node {
+	for _, name := range names {
+		n = n.Child(name)
+	}
+	return n
+}
+
+// Int returns the node's value as an integer. Strings holding a number are
+// converted, since Steam stores some numeric fields as text.
+func (n *Node) Int() (int64, bool) {
+	if n == nil {
+		return 0, false
+	}
+	switch v := n.Value.(type) {
+	case int32:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint64:
+		return int64(v), true
+	case float32:
+		return int64(v), true
+	case string:
+		i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		return i, err == nil
+	}
+	return 0, false
+}
+
+// String returns the node's value formatted as text, or "" for sections and
+// missing nodes.
+func (n *Node) String() string {
+	if n == nil || n.Type == TypeNone {
+		return ""
+	}
+	if s, ok := n.Value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(n.Value)
+}
@@ -0,0 +1,123 @@
+package keyvalues
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+	"unicode/utf16"
+)
+
+// entry builds the binary encoding of a KeyValues entry.
+func entry(t Type, name string, value any) []byte {
+	out := append([]byte{byte(t)}, name...)
+	out = append(out, 0)
+	switch v := value.(type) {
+	case string:
+		if t == TypeWideString {
+			for _, u := range utf16.Encode([]rune(v)) {
+				out = binary.LittleEndian.AppendUint16(out, u)
+			}
+			return append(out, 0, 0)
+		}
+		return append(append(out, v...), 0)
+	case int32:
+		return binary.LittleEndian.AppendUint32(out, uint32(v))
+	case float32:
+		return binary.LittleEndian.AppendUint32(out, math.Float32bits(v))
+	case uint32:
+		return binary.LittleEndian.AppendUint32(out, v)
+	case uint64:
+		return binary.LittleEndian.AppendUint64(out, v)
+	case int64:
+		return binary.LittleEndian.AppendUint64(out, uint64(v))
+	}
+	return out
+}
+
+func section(name string, children ...[]byte) []byte {
+	out := entry(TypeNone, name, nil)
+	for _, c := range children {
+		out = append(out, c...)
+	}
+	return append(out, byte(TypeEnd))
+}
+
+func TestParseBinary(t *testing.T) {
+	data := section("480",
+		entry(TypeString, "name", "Spacewar"),
+		entry(TypeWideString, "title", "Späcewar"),
+		entry(TypeInt32, "count", int32(-3)),
+		entry(TypeFloat32, "ratio", float32(0.5)),
+		entry(TypeColor, "color", uint32(0xff00ff)),
+		entry(TypeUint64, "steamid", uint64(76561198000000001)),
+		entry(TypeInt64, "delta", int64(-1)),
+		section("stats", entry(TypeString, "1", "42")),
+	)
+	data = append(data, byte(TypeEnd))
+
+	root, err := ParseBinary(data)
+	if err != nil {
+		t.Fatalf("ParseBinary: %v", err)
+	}
+	app := root.Child("480")
+
+	tests := []struct {
+		path []string
+		str  string
+		num  int64
+	}{
+		{[]string{"name"}, "Spacewar", 0},
+		{[]string{"TITLE"}, "Späcewar", 0},
+		{[]string{"count"}, "-3", -3},
+		{[]string{"ratio"}, "0.5", 0},
+		{[]string{"color"}, "16711935", 0xff00ff},
+		{[]string{"steamid"}, "76561198000000001", 76561198000000001},
+		{[]string{"delta"}, "-1", -1},
+		{[]string{"stats", "1"}, "42", 42},
+	}
+	for _, tt := range tests {
+		node := app.Path(tt.path...)
+		if node == nil {
+			t.Errorf("missing %v", tt.path)
+			continue
+		}
+		if got := node.String(); got != tt.str {
+			t.Errorf("%v String() = %q, want %q", tt.path, got, tt.str)
+		}
+		if n, ok := node.Int(); tt.num != 0 && (!ok || n != tt.num) {
+			t.Errorf("%v Int() = %d, %v; want %d", tt.path, n, ok, tt.num)
+		}
+	}
+
+	if app.Path("stats", "missing") != nil || app.Child("missing").String() != "" {
+		t.Error("missing nodes should be nil and print as empty")
+	}
+}
+
+func TestParseBinaryMalformed(t *testing.T) {
+	valid := append(section("480", entry(TypeInt32, "count", int32(1))), byte(TypeEnd))
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"unterminated section", valid[:len(valid)-2]},
+		{"truncated int", valid[:len(valid)-5]},
+		{"unterminated name", []byte{byte(TypeString), 'n', 'a'}},
+		{"unterminated wide string", []byte{byte(TypeWideString), 'w', 0, 'a', 0}},
+		{"unknown type", []byte{0x09, 'x', 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseBinary(tt.data); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	if _, err := ParseBinary(valid[:len(valid)-5]); !errors.Is(err, ErrTruncated) {
+		t.Errorf("truncated data: error = %v, want ErrTruncated", err)
+	}
+}
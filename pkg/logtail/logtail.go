@@ -0,0 +1,85 @@
+package logtail
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// Tailer reads lines appended to a log file. It follows the file by name, so
+// it picks up where a rotated log's replacement starts, and rereads from the
+// beginning when the file is truncated.
+type Tailer struct {
+	path    string
+	info    os.FileInfo
+	offset  int64
+	partial []byte
+	started bool
+}
+
+func New(path string) *Tailer {
+	return &Tailer{path: path}
+}
+
+func (t *Tailer) Path() string {
+	return t.path
+}
+
+// ReadLines returns the complete lines written since the last call, without
+// their line endings. The first call only skips to the end of the file, so
+// lines already in the log when tailing starts aren't reported. A missing
+// file is not an error; it is read from the start once it appears.
+func (t *Tailer) ReadLines() ([]string, error) {
+	info, err := os.Stat(t.path)
+	if os.IsNotExist(err) {
+		t.started = true
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !t.started {
+		t.started = true
+		t.info = info
+		t.offset = info.Size()
+		return nil, nil
+	}
+	if t.info == nil || !os.SameFile(t.info, info) || info.Size() < t.offset {
+		// Rotated or truncated: the whole file is new.
+		t.offset = 0
+		t.partial = nil
+	}
+	t.info = info
+	if info.Size() == t.offset {
+		return nil, nil
+	}
+
+	f, err := os.Open(t.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(t.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	t.offset += int64(len(data))
+
+	data = append(t.partial, data...)
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		t.partial = data
+		return nil, nil
+	}
+	t.partial = append([]byte(nil), data[end+1:]...)
+
+	var lines []string
+	for _, line := range bytes.Split(data[:end], []byte{'\n'}) {
+		lines = append(lines, string(bytes.TrimSuffix(line, []byte{'\r'})))
+	}
+	return lines, nil
+}
@@ -0,0 +1,95 @@
+package logtail
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func appendFile(t *testing.T, path string, data string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expectLines(t *testing.T, tailer *Tailer, want ...string) {
+	t.Helper()
+	got, err := tailer.ReadLines()
+	if err != nil {
+		t.Fatalf("ReadLines: %v", err)
+	}
+	if len(got) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadLines = %q, want %q", got, want)
+	}
+}
+
+func TestReadLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "game.log")
+	appendFile(t, path, "old line\n")
+
+	tailer := New(path)
+	expectLines(t, tailer)
+
+	appendFile(t, path, "first\r\nsecond\n")
+	expectLines(t, tailer, "first", "second")
+	expectLines(t, tailer)
+
+	// A partial line is held back until its line ending arrives.
+	appendFile(t, path, "thi")
+	expectLines(t, tailer)
+	appendFile(t, path, "rd\nfou")
+	expectLines(t, tailer, "third")
+	appendFile(t, path, "rth\n")
+	expectLines(t, tailer, "fourth")
+}
+
+func TestReadLinesTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "game.log")
+	appendFile(t, path, "a fairly long line that was already there\n")
+
+	tailer := New(path)
+	expectLines(t, tailer)
+
+	if err := os.WriteFile(path, []byte("new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectLines(t, tailer, "new")
+}
+
+func TestReadLinesRotated(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "game.log")
+	appendFile(t, path, "old line\n")
+
+	tailer := New(path)
+	expectLines(t, tailer)
+	appendFile(t, path, "before rotation\n")
+	expectLines(t, tailer, "before rotation")
+
+	if err := os.Rename(path, filepath.Join(dir, "game.log.1")); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path, "a longer line after rotation\n")
+	expectLines(t, tailer, "a longer line after rotation")
+}
+
+func TestReadLinesMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "game.log")
+
+	tailer := New(path)
+	expectLines(t, tailer)
+
+	// A log that appears after tailing started is read from the start.
+	appendFile(t, path, "created\n")
+	expectLines(t, tailer, "created")
+}